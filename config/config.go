@@ -3,18 +3,52 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 
 	"sigs.k8s.io/yaml"
 
 	"github.com/telia-oss/sidecred"
-	"github.com/telia-oss/sidecred/provider/artifactory"
-	"github.com/telia-oss/sidecred/provider/github"
-	"github.com/telia-oss/sidecred/provider/random"
-	"github.com/telia-oss/sidecred/provider/sts"
+
+	// Blank imported for their registration side effects: each of these
+	// packages calls sidecred.RegisterProvider, sidecred.RegisterStore or
+	// sidecred.RegisterDiscoverer from an init() function. A new provider,
+	// store or discoverer is made available to configuration files by adding
+	// its import here; nothing else in this package changes.
+	_ "github.com/telia-oss/sidecred/discovery/github"
+	_ "github.com/telia-oss/sidecred/provider/artifactory"
+	_ "github.com/telia-oss/sidecred/provider/github"
+	_ "github.com/telia-oss/sidecred/provider/pki"
+	_ "github.com/telia-oss/sidecred/provider/random"
+	_ "github.com/telia-oss/sidecred/provider/ssh"
+	_ "github.com/telia-oss/sidecred/provider/sts"
+	_ "github.com/telia-oss/sidecred/provider/vault"
+	_ "github.com/telia-oss/sidecred/store/githubsecrets"
+	_ "github.com/telia-oss/sidecred/store/inprocess"
+	_ "github.com/telia-oss/sidecred/store/secretsmanager"
+	_ "github.com/telia-oss/sidecred/store/ssm"
+	_ "github.com/telia-oss/sidecred/store/vault"
 )
 
-// Parse a YAML (or JSON) representation of sidecred.Config.
-func Parse(b []byte) (cfg sidecred.Config, err error) {
+// Parse a YAML (or JSON) representation of sidecred.Config. Version 2
+// configs that use `includes:` resolve them relative to the current working
+// directory; use ParseFile to resolve them relative to the config file
+// itself.
+func Parse(b []byte) (sidecred.Config, error) {
+	return parse(b, "")
+}
+
+// ParseFile reads and parses the sidecred.Config at path, resolving version 2
+// `includes:` relative to its directory.
+func ParseFile(path string) (sidecred.Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %s", err)
+	}
+	return parse(b, filepath.Dir(path))
+}
+
+func parse(b []byte, baseDir string) (cfg sidecred.Config, err error) {
 	var t struct {
 		Version *int `json:"version"`
 	}
@@ -30,6 +64,13 @@ func Parse(b []byte) (cfg sidecred.Config, err error) {
 		var v1 *v1
 		err = yaml.UnmarshalStrict(b, &v1)
 		cfg = v1
+	case 2:
+		var v2 *v2
+		err = yaml.UnmarshalStrict(b, &v2)
+		if err == nil {
+			v2.baseDir = baseDir
+			cfg = v2
+		}
 	default:
 		return nil, fmt.Errorf("unknown configuration version (v%d)", *t.Version)
 	}
@@ -77,9 +118,7 @@ func (c *v1) Validate() error {
 
 	stores := make(map[string]struct{}, len(c.CredentialStores))
 	for i, s := range c.CredentialStores {
-		switch s.Type {
-		case sidecred.Inprocess, sidecred.SSM, sidecred.SecretsManager, sidecred.GithubSecrets:
-		default:
+		if !sidecred.IsRegisteredStore(s.Type) {
 			return fmt.Errorf("stores[%d]: unknown type %q", i, string(s.Type))
 		}
 		if _, found := stores[s.Alias()]; found {
@@ -137,13 +176,13 @@ func (c *requestV1) credentialsMap() *sidecred.CredentialsMap {
 // 1. As a regular CredentialRequest.
 // 2. As a list of requests that share a CredentialType (nested credential requests should omit "type"):
 //
-//  - type: aws:sts
-//    list:
-// 	    - name: credential1
-//        config ...
-// 	    - name: credential2
-//        config ...
-//
+//	creds:
+//	  - type: aws:sts
+//	    list:
+//	      - name: credential1
+//	        config ...
+//	      - name: credential2
+//	        config ...
 type credentialRequest struct {
 	*sidecred.CredentialRequest `json:",inline"`
 	List                        []*sidecred.CredentialRequest `json:"list,omitempty"`
@@ -185,22 +224,13 @@ func (c *credentialRequest) flatten() []*sidecred.CredentialRequest {
 	return requests
 }
 
-// parseProviderConfig from JSON.
+// parseProviderConfig from JSON, looking up the CredentialType in the
+// provider registry rather than hardcoding every known provider here. See
+// sidecred.RegisterProvider.
 func parseProviderConfig(t sidecred.CredentialType, config json.RawMessage) (sidecred.Validatable, error) {
-	var c sidecred.Validatable
-	switch t {
-	case sidecred.AWSSTS:
-		c = &sts.RequestConfig{}
-	case sidecred.GithubAccessToken:
-		c = &github.AccessTokenRequestConfig{}
-	case sidecred.GithubDeployKey:
-		c = &github.DeployKeyRequestConfig{}
-	case sidecred.ArtifactoryAccessToken:
-		c = &artifactory.RequestConfig{}
-	case sidecred.Randomized:
-		c = &random.RequestConfig{}
-	default:
-		return nil, fmt.Errorf("unknown type %q", string(t))
+	c, err := sidecred.ProviderConfig(t)
+	if err != nil {
+		return nil, err
 	}
 	if err := sidecred.UnmarshalConfig(config, c); err != nil {
 		return nil, fmt.Errorf("unmarshal config: %s", err)
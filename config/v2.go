@@ -0,0 +1,399 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/template"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/telia-oss/sidecred"
+)
+
+var _ sidecred.Config = &v2{}
+
+// v2 adds includes, variable templating, per-type defaults and for_each
+// generators on top of the v1 schema, then resolves itself into an
+// equivalent v1 for validation and iteration, so both versions share exactly
+// the same semantics once rendered. Render returns the result of that
+// resolution as YAML.
+type v2 struct {
+	Version             int                                    `json:"version"`
+	CredentialNamespace string                                 `json:"namespace"`
+	Variables           map[string]string                      `json:"variables,omitempty"`
+	Defaults            map[sidecred.CredentialType]defaultsV2 `json:"defaults,omitempty"`
+	Includes            []string                               `json:"includes,omitempty"`
+	CredentialStores    []json.RawMessage                      `json:"stores,omitempty"`
+	CredentialRequests  []*requestV2                           `json:"requests,omitempty"`
+
+	// baseDir is the directory Includes are resolved relative to. Set by
+	// ParseFile; empty (i.e. relative to the working directory) when parsed
+	// from raw bytes via Parse.
+	baseDir string
+
+	resolveOnce sync.Once
+	resolved    *v1
+	resolveErr  error
+}
+
+// defaultsV2 holds the per-CredentialType defaults applied to requests that
+// don't set the corresponding field themselves.
+type defaultsV2 struct {
+	RotationWindow time.Duration `json:"rotation_window,omitempty"`
+}
+
+// UnmarshalJSON reads rotation_window as a duration string such as "1h".
+func (d *defaultsV2) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		RotationWindow string `json:"rotation_window,omitempty"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil || raw.RotationWindow == "" {
+		return err
+	}
+	v, err := time.ParseDuration(raw.RotationWindow)
+	if err != nil {
+		return fmt.Errorf("%q: %s", "rotation_window", err)
+	}
+	d.RotationWindow = v
+	return nil
+}
+
+type requestV2 struct {
+	Store string                 `json:"store"`
+	Creds []*credentialRequestV2 `json:"creds"`
+}
+
+// credentialRequestV2 is a requestV1 entry with three additions: Name and
+// Config are rendered as Go templates before use; ForEach generalizes v1's
+// "list" hack to expand a single entry into N requests over arbitrary
+// per-entry variables (rather than just name/config pairs); and Discover
+// generates that same per-entry variable list at run time instead of
+// spelling it out statically. ForEach and Discover are mutually exclusive.
+type credentialRequestV2 struct {
+	Type           sidecred.CredentialType `json:"type,omitempty"`
+	Name           string                  `json:"name,omitempty"`
+	RotationWindow time.Duration           `json:"rotation_window,omitempty"`
+	Config         json.RawMessage         `json:"config,omitempty"`
+	ForEach        []map[string]string     `json:"for_each,omitempty"`
+	Discover       *discoverConfig         `json:"discover,omitempty"`
+}
+
+// discoverConfig names a registered sidecred.Discoverer and the config it's
+// called with.
+type discoverConfig struct {
+	Type   sidecred.DiscoveryType `json:"type"`
+	Config json.RawMessage        `json:"config,omitempty"`
+}
+
+// Namespace implements sidecred.Config.
+func (c *v2) Namespace() string {
+	v1, err := c.resolve()
+	if err != nil {
+		return ""
+	}
+	return v1.Namespace()
+}
+
+// Stores implements sidecred.Config.
+func (c *v2) Stores() []*sidecred.StoreConfig {
+	v1, err := c.resolve()
+	if err != nil {
+		return nil
+	}
+	return v1.Stores()
+}
+
+// Requests implements sidecred.Config.
+func (c *v2) Requests() []*sidecred.CredentialsMap {
+	v1, err := c.resolve()
+	if err != nil {
+		return nil
+	}
+	return v1.Requests()
+}
+
+// Validate implements sidecred.Config.
+func (c *v2) Validate() error {
+	v1, err := c.resolve()
+	if err != nil {
+		return err
+	}
+	return v1.Validate()
+}
+
+// resolve merges includes, renders templates and expands for_each exactly
+// once, caching the resulting v1-equivalent config for the lifetime of c.
+func (c *v2) resolve() (*v1, error) {
+	c.resolveOnce.Do(func() {
+		c.resolved, c.resolveErr = c.build()
+	})
+	return c.resolved, c.resolveErr
+}
+
+// build performs the actual merge/render/expand pipeline described on v2.
+func (c *v2) build() (*v1, error) {
+	merged, err := c.mergeIncludes()
+	if err != nil {
+		return nil, fmt.Errorf("includes: %s", err)
+	}
+
+	render, err := newRenderer(merged.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := render("namespace", merged.CredentialNamespace, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &v1{
+		Version:             1,
+		CredentialNamespace: namespace,
+	}
+
+	for i, raw := range merged.CredentialStores {
+		rendered, err := renderJSON(render, fmt.Sprintf("stores[%d]", i), raw, nil)
+		if err != nil {
+			return nil, fmt.Errorf("stores[%d]: %s", i, err)
+		}
+		store := &sidecred.StoreConfig{}
+		if err := json.Unmarshal(rendered, store); err != nil {
+			return nil, fmt.Errorf("stores[%d]: unmarshal: %s", i, err)
+		}
+		out.CredentialStores = append(out.CredentialStores, store)
+	}
+
+	for i, r := range merged.CredentialRequests {
+		creds, err := expandRequest(r, merged.Defaults, render)
+		if err != nil {
+			return nil, fmt.Errorf("requests[%d]: %s", i, err)
+		}
+		out.CredentialRequests = append(out.CredentialRequests, &requestV1{
+			Store: r.Store,
+			Creds: creds,
+		})
+	}
+	return out, nil
+}
+
+// expandRequest renders and expands a single requestV2 entry's creds,
+// including ForEach generation, into the credentialRequest type v1 already
+// knows how to validate and flatten.
+func expandRequest(r *requestV2, defaults map[sidecred.CredentialType]defaultsV2, render renderFunc) ([]*credentialRequest, error) {
+	var out []*credentialRequest
+	for i, cred := range r.Creds {
+		if len(cred.ForEach) > 0 && cred.Discover != nil {
+			return nil, fmt.Errorf("creds[%d]: only one of %q or %q may be set", i, "for_each", "discover")
+		}
+		hasGenerator := len(cred.ForEach) > 0 || cred.Discover != nil
+		entries := cred.ForEach
+		if cred.Discover != nil {
+			discovered, err := discover(cred.Discover)
+			if err != nil {
+				return nil, fmt.Errorf("creds[%d]: discover: %s", i, err)
+			}
+			entries = discovered
+		}
+		// An empty for_each/discover result means zero requests, not one
+		// rendered with no .item -- only fall back to a single nil-item
+		// entry when neither generator was configured at all.
+		if len(entries) == 0 && !hasGenerator {
+			entries = []map[string]string{nil}
+		}
+		for _, entry := range entries {
+			name, err := render("name", cred.Name, entry)
+			if err != nil {
+				return nil, fmt.Errorf("creds[%d]: %s", i, err)
+			}
+			config, err := renderJSON(render, "config", cred.Config, entry)
+			if err != nil {
+				return nil, fmt.Errorf("creds[%d]: %s", i, err)
+			}
+			rotationWindow := cred.RotationWindow
+			if rotationWindow == 0 {
+				rotationWindow = defaults[cred.Type].RotationWindow
+			}
+			out = append(out, &credentialRequest{
+				CredentialRequest: &sidecred.CredentialRequest{
+					Type:           cred.Type,
+					Name:           name,
+					RotationWindow: rotationWindow,
+					Config:         config,
+				},
+			})
+		}
+	}
+	return out, nil
+}
+
+// discover runs the sidecred.Discoverer named by c and returns the targets it
+// found. v2.resolveOnce already ensures this runs at most once per config
+// instance, so validation and apply see the same, stable set of targets.
+func discover(c *discoverConfig) ([]map[string]string, error) {
+	discoverer, err := sidecred.NewDiscoverer(c.Type)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := discoverer.Discover(c.Config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", c.Type, err)
+	}
+	return targets, nil
+}
+
+// renderFunc renders a Go template in s, with `.var` bound to the config's
+// top-level variables and, when non-nil, `.item` bound to a for_each entry.
+type renderFunc func(field, s string, item map[string]string) (string, error)
+
+// newRenderer builds a renderFunc closed over vars and the `env` template
+// function.
+func newRenderer(vars map[string]string) (renderFunc, error) {
+	return func(field, s string, item map[string]string) (string, error) {
+		if s == "" {
+			return s, nil
+		}
+		tmpl, err := template.New(field).Funcs(template.FuncMap{"env": os.Getenv}).Parse(s)
+		if err != nil {
+			return "", fmt.Errorf("parse %q template: %s", field, err)
+		}
+		var buf bytes.Buffer
+		data := map[string]interface{}{"var": vars, "item": item}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("render %q template: %s", field, err)
+		}
+		return buf.String(), nil
+	}, nil
+}
+
+// mergeIncludes reads c.Includes (each itself a v2 document contributing
+// stores/requests/variables/defaults) relative to c.baseDir and appends their
+// content to a copy of c. Variables and defaults declared at the top level
+// take precedence over included ones on conflict.
+func (c *v2) mergeIncludes() (*v2, error) {
+	merged := &v2{
+		CredentialNamespace: c.CredentialNamespace,
+		Variables:           map[string]string{},
+		Defaults:            map[sidecred.CredentialType]defaultsV2{},
+		CredentialStores:    append([]json.RawMessage{}, c.CredentialStores...),
+		CredentialRequests:  append([]*requestV2{}, c.CredentialRequests...),
+	}
+	for _, path := range c.Includes {
+		full := path
+		if !filepath.IsAbs(full) {
+			full = filepath.Join(c.baseDir, path)
+		}
+		b, err := ioutil.ReadFile(full)
+		if err != nil {
+			return nil, fmt.Errorf("read %q: %s", path, err)
+		}
+		var part v2
+		if err := yaml.UnmarshalStrict(b, &part); err != nil {
+			return nil, fmt.Errorf("unmarshal %q: %s", path, err)
+		}
+		if len(part.Includes) > 0 {
+			return nil, fmt.Errorf("%q: nested includes are not supported", path)
+		}
+		for k, v := range part.Variables {
+			merged.Variables[k] = v
+		}
+		for k, v := range part.Defaults {
+			merged.Defaults[k] = v
+		}
+		merged.CredentialStores = append(merged.CredentialStores, part.CredentialStores...)
+		merged.CredentialRequests = append(merged.CredentialRequests, part.CredentialRequests...)
+	}
+	for k, v := range c.Variables {
+		merged.Variables[k] = v
+	}
+	for k, v := range c.Defaults {
+		merged.Defaults[k] = v
+	}
+	return merged, nil
+}
+
+// renderJSON decodes raw as a generic JSON value, renders every string leaf
+// as a Go template via render, then re-encodes it. Rendering at the decoded
+// value level rather than on raw's bytes means a substituted value can never
+// break out of the JSON string it's assigned to: json.Marshal escapes it
+// like any other string, however it was produced.
+func renderJSON(render renderFunc, field string, raw json.RawMessage, item map[string]string) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("unmarshal %q: %s", field, err)
+	}
+	rendered, err := renderValue(render, field, v, item)
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %q: %s", field, err)
+	}
+	return out, nil
+}
+
+// renderValue recurses through a decoded JSON value, rendering string leaves
+// as templates and leaving every other type (including map keys) untouched.
+func renderValue(render renderFunc, field string, v interface{}, item map[string]string) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		return render(field, t, item)
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			r, err := renderValue(render, field, e, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			r, err := renderValue(render, field, e, item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// Render marshals cfg into the fully-resolved v1-equivalent YAML, so authors
+// can review exactly what a v2 config (includes merged, variables
+// substituted, for_each expanded) resolves to. No CLI command calls it.
+// Validate is checked first so a config that fails to resolve (a
+// bad include, template or discover block) is reported as an error instead
+// of rendering as an empty-but-valid v1 document.
+func Render(cfg sidecred.Config) ([]byte, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %s", err)
+	}
+	out := &v1{
+		Version:             1,
+		CredentialNamespace: cfg.Namespace(),
+		CredentialStores:    cfg.Stores(),
+	}
+	for _, entry := range cfg.Requests() {
+		r := &requestV1{Store: entry.Store}
+		for _, c := range entry.Credentials {
+			r.Creds = append(r.Creds, &credentialRequest{CredentialRequest: c})
+		}
+		out.CredentialRequests = append(out.CredentialRequests, r)
+	}
+	return yaml.Marshal(out)
+}
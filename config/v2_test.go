@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/telia-oss/sidecred"
+)
+
+// fakeDiscoverer returns a fixed set of targets, one of which carries a
+// value with characters that would corrupt the request's config JSON if
+// substituted in as a raw string rather than rendered through the JSON tree.
+type fakeDiscoverer struct{}
+
+func (fakeDiscoverer) Type() sidecred.DiscoveryType { return "test-fake" }
+func (fakeDiscoverer) Discover(config json.RawMessage) ([]map[string]string, error) {
+	return []map[string]string{
+		{"name": `repo with "quotes" and \backslash`},
+	}, nil
+}
+
+func init() {
+	sidecred.RegisterDiscoverer(sidecred.DiscovererRegistration{
+		Type:        "test-fake",
+		New:         func() sidecred.Discoverer { return fakeDiscoverer{} },
+		Description: "test-only discoverer used by config package tests",
+	})
+}
+
+func TestV2MergesIncludedDefaults(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "included.yaml", `
+version: 2
+defaults:
+  "vault:secret":
+    rotation_window: 1h
+`)
+	b := []byte(`
+version: 2
+namespace: test
+includes:
+  - included.yaml
+stores:
+  - type: vault
+requests:
+  - store: vault
+    creds:
+      - type: vault:secret
+        name: role
+        config: {}
+`)
+	cfg, err := parse(b, dir)
+	if err != nil {
+		t.Fatalf("parse() = %s", err)
+	}
+	requests := cfg.Requests()
+	if len(requests) != 1 || len(requests[0].Credentials) != 1 {
+		t.Fatalf("unexpected requests: %+v", requests)
+	}
+	if got := requests[0].Credentials[0].RotationWindow; got.String() != "1h0m0s" {
+		t.Fatalf("rotation_window = %s, want 1h0m0s (from included defaults)", got)
+	}
+}
+
+func TestV2RendersStoreAlias(t *testing.T) {
+	b := []byte(`
+version: 2
+namespace: test
+variables:
+  env: production
+stores:
+  - type: vault
+    alias: "store-{{ .var.env }}"
+requests: []
+`)
+	cfg, err := parse(b, "")
+	if err != nil {
+		t.Fatalf("parse() = %s", err)
+	}
+	stores := cfg.Stores()
+	if len(stores) != 1 {
+		t.Fatalf("got %d stores, want 1", len(stores))
+	}
+	if got := stores[0].Alias(); got != "store-production" {
+		t.Fatalf("alias = %q, want %q", got, "store-production")
+	}
+}
+
+func TestV2EscapesDiscoveredValuesInConfig(t *testing.T) {
+	b := []byte(`
+version: 2
+namespace: test
+stores:
+  - type: vault
+requests:
+  - store: vault
+    creds:
+      - type: vault:secret
+        name: "{{ .item.name }}"
+        config:
+          role: "{{ .item.name }}"
+        discover:
+          type: test-fake
+`)
+	cfg, err := parse(b, "")
+	if err != nil {
+		t.Fatalf("parse() = %s", err)
+	}
+	requests := cfg.Requests()
+	if len(requests) != 1 || len(requests[0].Credentials) != 1 {
+		t.Fatalf("unexpected requests: %+v", requests)
+	}
+	cred := requests[0].Credentials[0]
+
+	var decoded struct {
+		Role string `json:"role"`
+	}
+	if err := json.Unmarshal(cred.Config, &decoded); err != nil {
+		t.Fatalf("config is not valid JSON after templating: %s\nraw: %s", err, cred.Config)
+	}
+	want := `repo with "quotes" and \backslash`
+	if decoded.Role != want {
+		t.Fatalf("role = %q, want %q", decoded.Role, want)
+	}
+}
+
+func TestV2ForEachAndDiscoverAreMutuallyExclusive(t *testing.T) {
+	b := []byte(`
+version: 2
+namespace: test
+stores:
+  - type: vault
+requests:
+  - store: vault
+    creds:
+      - type: vault:secret
+        name: dup
+        config: {}
+        for_each:
+          - key: value
+        discover:
+          type: test-fake
+`)
+	cfg, err := parse(b, "")
+	if err != nil {
+		t.Fatalf("parse() = %s", err)
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Validate() = nil, want error (for_each and discover both set)")
+	}
+}
+
+func TestV2RejectsNestedIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "leaf.yaml", `
+version: 2
+`)
+	writeFile(t, dir, "included.yaml", `
+version: 2
+includes:
+  - leaf.yaml
+`)
+	b := []byte(`
+version: 2
+namespace: test
+includes:
+  - included.yaml
+stores:
+  - type: vault
+requests: []
+`)
+	cfg, err := parse(b, dir)
+	if err != nil {
+		t.Fatalf("parse() = %s", err)
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Validate() = nil, want error (included.yaml has its own includes)")
+	}
+}
+
+func TestRenderReturnsValidateError(t *testing.T) {
+	b := []byte(`
+version: 2
+namespace: test
+stores:
+  - type: vault
+requests:
+  - store: vault
+    creds:
+      - type: vault:secret
+        name: dup
+        config: {}
+        for_each:
+          - key: value
+        discover:
+          type: test-fake
+`)
+	cfg, err := parse(b, "")
+	if err != nil {
+		t.Fatalf("parse() = %s", err)
+	}
+	if _, err := Render(cfg); err == nil {
+		t.Fatal("Render() = nil error, want error (for_each and discover both set)")
+	}
+}
+
+func writeFile(t *testing.T, dir, name, contents string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+		t.Fatalf("write %s: %s", name, err)
+	}
+}
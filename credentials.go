@@ -0,0 +1,18 @@
+package sidecred
+
+// VaultSecret is the CredentialType issued by provider/vault for dynamic
+// secrets read from a Vault secrets engine (database, aws, kv-v2).
+const VaultSecret CredentialType = "vault:secret"
+
+// Vault is the StoreType implemented by store/vault, which persists issued
+// secrets to a Vault KV v2 path.
+const Vault StoreType = "vault"
+
+// X509Certificate is the CredentialType issued by provider/pki: a signed
+// leaf certificate, its chain, and the matching private key.
+const X509Certificate CredentialType = "x509:certificate"
+
+// SSHCertificate is the CredentialType issued by provider/ssh: a signed SSH
+// user or host certificate, and the matching private key when the provider
+// generated the keypair itself.
+const SSHCertificate CredentialType = "ssh:certificate"
@@ -0,0 +1,74 @@
+package sidecred
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// DiscoveryType identifies a Discoverer.
+type DiscoveryType string
+
+// Discoverer expands a single `discover:` block into a set of targets found
+// at run time (e.g. GitHub repos matching a topic, AWS accounts in an
+// Organization, Kubernetes namespaces matching a label selector). Each
+// target is a flat string map that config v2's for_each expansion binds to
+// `.item` when rendering a request's name/config templates, so a discovery
+// block behaves like a dynamically generated for_each list.
+type Discoverer interface {
+	Type() DiscoveryType
+	Discover(config json.RawMessage) ([]map[string]string, error)
+}
+
+// DiscovererFactory returns a new, unconfigured Discoverer.
+type DiscovererFactory func() Discoverer
+
+// DiscovererRegistration describes a discoverer that has made itself
+// available for use in a `discover:` block.
+type DiscovererRegistration struct {
+	// Type is the DiscoveryType handled by the discoverer.
+	Type DiscoveryType
+
+	// New returns a new Discoverer for Type.
+	New DiscovererFactory
+
+	// Description is a short, human readable summary of the discoverer. It is
+	// not included by ListProviders or FormatProviders, which cover providers
+	// only.
+	Description string
+}
+
+// discovererRegistry holds discoverers registered with RegisterDiscoverer,
+// keyed by DiscoveryType.
+var discovererRegistry = map[DiscoveryType]*DiscovererRegistration{}
+
+// RegisterDiscoverer registers a discoverer's DiscoveryType and constructor.
+// Discoverers are expected to call this from an init() function, the same
+// way providers call RegisterProvider, so importing the package for its side
+// effect is enough to make a `discover: { type: ... }` block resolvable.
+func RegisterDiscoverer(reg DiscovererRegistration) {
+	if _, found := discovererRegistry[reg.Type]; found {
+		panic(fmt.Sprintf("discoverer for type %q already registered", string(reg.Type)))
+	}
+	discovererRegistry[reg.Type] = &reg
+}
+
+// NewDiscoverer constructs the Discoverer registered for t, or returns an
+// error if nothing has registered itself for that DiscoveryType.
+func NewDiscoverer(t DiscoveryType) (Discoverer, error) {
+	reg, found := discovererRegistry[t]
+	if !found {
+		return nil, fmt.Errorf("unknown discovery type %q", string(t))
+	}
+	return reg.New(), nil
+}
+
+// Discoverers returns the registered discovery types, sorted by DiscoveryType.
+func Discoverers() []*DiscovererRegistration {
+	out := make([]*DiscovererRegistration, 0, len(discovererRegistry))
+	for _, reg := range discovererRegistry {
+		out = append(out, reg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
@@ -0,0 +1,155 @@
+// Package github implements a sidecred.Discoverer that lists GitHub repositories
+// belonging to an organization, optionally filtered by topic.
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/telia-oss/sidecred"
+)
+
+func init() {
+	sidecred.RegisterDiscoverer(sidecred.DiscovererRegistration{
+		Type:        Type,
+		New:         func() sidecred.Discoverer { return New(http.DefaultClient) },
+		Description: "Lists GitHub repositories in an organization, optionally filtered by topic.",
+	})
+}
+
+// Type is the DiscoveryType this package registers.
+const Type sidecred.DiscoveryType = "github-repos"
+
+// perPage bounds each GitHub API request; results are paginated until a page
+// comes back short.
+const perPage = 100
+
+// Config selects which repositories Discover lists.
+type Config struct {
+	// APIURL defaults to https://api.github.com.
+	APIURL string `json:"api_url,omitempty"`
+
+	// Org is the GitHub organization to list repositories from.
+	Org string `json:"org"`
+
+	// Topic, when set, restricts results to repositories tagged with it.
+	Topic string `json:"topic,omitempty"`
+
+	// Token authenticates requests against the GitHub API.
+	Token string `json:"token"`
+}
+
+// Validate implements sidecred.Validatable.
+func (c *Config) Validate() error {
+	if c.Org == "" {
+		return fmt.Errorf("%q must be defined", "org")
+	}
+	return nil
+}
+
+// repository is the subset of GitHub's repository object Discoverer cares about.
+type repository struct {
+	Name     string   `json:"name"`
+	FullName string   `json:"full_name"`
+	CloneURL string   `json:"clone_url"`
+	Topics   []string `json:"topics"`
+}
+
+// Discoverer lists repositories in a GitHub organization.
+type Discoverer struct {
+	client *http.Client
+}
+
+// New Discoverer using client to talk to the GitHub API.
+func New(client *http.Client) *Discoverer {
+	return &Discoverer{client: client}
+}
+
+// Type implements sidecred.Discoverer.
+func (d *Discoverer) Type() sidecred.DiscoveryType {
+	return Type
+}
+
+// Discover implements sidecred.Discoverer. Each target map has "name",
+// "full_name" and "clone_url" keys for use in a request's name/config
+// templates.
+func (d *Discoverer) Discover(config json.RawMessage) ([]map[string]string, error) {
+	c := &Config{}
+	if err := sidecred.UnmarshalConfig(config, c); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %s", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %s", err)
+	}
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+
+	var targets []map[string]string
+	for page := 1; ; page++ {
+		repos, err := d.listRepos(apiURL, c, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range repos {
+			if c.Topic != "" && !hasTopic(r.Topics, c.Topic) {
+				continue
+			}
+			targets = append(targets, map[string]string{
+				"name":      r.Name,
+				"full_name": r.FullName,
+				"clone_url": r.CloneURL,
+			})
+		}
+		if len(repos) < perPage {
+			break
+		}
+	}
+	return targets, nil
+}
+
+func (d *Discoverer) listRepos(apiURL string, c *Config, page int) ([]*repository, error) {
+	u := fmt.Sprintf("%s/orgs/%s/repos?%s", apiURL, url.PathEscape(c.Org), url.Values{
+		"per_page": {strconv.Itoa(perPage)},
+		"page":     {strconv.Itoa(page)},
+	}.Encode())
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %s", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("token %s", c.Token))
+	req.Header.Set("Accept", "application/vnd.github.mercy-preview+json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, sidecred.NewRecoverableError(fmt.Errorf("list repos: %s", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, sidecred.NewRecoverableError(fmt.Errorf("list repos: unexpected status %s", resp.Status))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list repos: unexpected status %s", resp.Status)
+	}
+
+	var repos []*repository
+	if err := json.NewDecoder(resp.Body).Decode(&repos); err != nil {
+		return nil, fmt.Errorf("decode response: %s", err)
+	}
+	return repos, nil
+}
+
+func hasTopic(topics []string, topic string) bool {
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr bool
+	}{
+		{name: "valid", config: &Config{Org: "telia-oss"}, wantErr: false},
+		{name: "missing org", config: &Config{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiscoverFiltersByTopicAndPaginates(t *testing.T) {
+	page1 := make([]repository, perPage)
+	for i := range page1 {
+		page1[i] = repository{Name: "repo", FullName: "org/repo", CloneURL: "url", Topics: []string{"other"}}
+	}
+	page2 := []repository{
+		{Name: "matching", FullName: "org/matching", CloneURL: "https://example.com/matching.git", Topics: []string{"sidecred"}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "1" {
+			json.NewEncoder(w).Encode(page1)
+			return
+		}
+		json.NewEncoder(w).Encode(page2)
+	}))
+	defer server.Close()
+
+	d := New(server.Client())
+	config, err := json.Marshal(&Config{APIURL: server.URL, Org: "telia-oss", Topic: "sidecred", Token: "t"})
+	if err != nil {
+		t.Fatalf("marshal config: %s", err)
+	}
+
+	targets, err := d.Discover(config)
+	if err != nil {
+		t.Fatalf("Discover() = %s", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("got %d targets, want 1", len(targets))
+	}
+	if targets[0]["name"] != "matching" || targets[0]["clone_url"] != "https://example.com/matching.git" {
+		t.Fatalf("target = %+v, want matching/clone_url", targets[0])
+	}
+}
+
+func TestDiscoverRejectsMissingOrg(t *testing.T) {
+	d := New(http.DefaultClient)
+	config, _ := json.Marshal(&Config{})
+	if _, err := d.Discover(config); err == nil {
+		t.Fatal("Discover() = nil, want error")
+	}
+}
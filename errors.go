@@ -0,0 +1,35 @@
+package sidecred
+
+import "errors"
+
+// RecoverableError wraps an error that stems from a transient condition
+// (network blip, rate limiting, a 5xx from an upstream API) rather than a
+// configuration or authentication problem. Process retries errors of this
+// type with backoff instead of aborting the run; providers and stores should
+// wrap transient failures in a RecoverableError and leave everything else
+// (bad credentials, invalid config, 4xx other than rate limiting) bare so it
+// aborts immediately.
+type RecoverableError struct {
+	err error
+}
+
+// NewRecoverableError wraps err, marking it as recoverable.
+func NewRecoverableError(err error) *RecoverableError {
+	return &RecoverableError{err: err}
+}
+
+// Error implements error.
+func (e *RecoverableError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap implements errors.Unwrap.
+func (e *RecoverableError) Unwrap() error {
+	return e.err
+}
+
+// IsRecoverable reports whether err, or any error it wraps, is a RecoverableError.
+func IsRecoverable(err error) bool {
+	var r *RecoverableError
+	return errors.As(err, &r)
+}
@@ -0,0 +1,286 @@
+// Package vault provides a Vault API client shared by provider/vault and
+// store/vault, handling AppRole, Kubernetes and token authentication.
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// defaultKubernetesJWTPath is where Kubernetes projects the pod's service
+// account token by default.
+const defaultKubernetesJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// AuthConfig selects how Client authenticates against Vault. Exactly one of
+// AppRole, Kubernetes or Token should be set.
+type AuthConfig struct {
+	AppRole    *AppRoleAuth    `json:"app_role,omitempty"`
+	Kubernetes *KubernetesAuth `json:"kubernetes,omitempty"`
+	Token      string          `json:"token,omitempty"`
+}
+
+// AppRoleAuth authenticates against the AppRole auth method.
+type AppRoleAuth struct {
+	Mount    string `json:"mount"`
+	RoleID   string `json:"role_id"`
+	SecretID string `json:"secret_id"`
+}
+
+// KubernetesAuth authenticates against the Kubernetes auth method by
+// exchanging the pod's projected service account token for a Vault token.
+type KubernetesAuth struct {
+	Mount string `json:"mount"`
+	Role  string `json:"role"`
+
+	// JWTPath overrides the path the service account token is read from.
+	// Defaults to the standard projected token path.
+	JWTPath string `json:"jwt_path,omitempty"`
+}
+
+// validate checks that exactly one auth method is set and has the fields it
+// needs. NewClient calls it; AuthConfig is never a top-level request config,
+// so it is not registered as a sidecred.Validatable of its own.
+func (c *AuthConfig) validate() error {
+	if c == nil {
+		return fmt.Errorf("exactly one of %q, %q or %q must be set", "app_role", "kubernetes", "token")
+	}
+	var set int
+	for _, ok := range []bool{c.AppRole != nil, c.Kubernetes != nil, c.Token != ""} {
+		if ok {
+			set++
+		}
+	}
+	switch {
+	case set == 0:
+		return fmt.Errorf("exactly one of %q, %q or %q must be set", "app_role", "kubernetes", "token")
+	case set > 1:
+		return fmt.Errorf("only one of %q, %q or %q may be set", "app_role", "kubernetes", "token")
+	}
+	if c.AppRole != nil && (c.AppRole.RoleID == "" || c.AppRole.SecretID == "") {
+		return fmt.Errorf("%q and %q must be defined", "role_id", "secret_id")
+	}
+	if c.Kubernetes != nil && c.Kubernetes.Role == "" {
+		return fmt.Errorf("%q must be defined", "role")
+	}
+	return nil
+}
+
+// Client wraps a Vault API client that has authenticated using one of the
+// methods in AuthConfig. It is shared by the provider and the store so both
+// can be configured with a single address and auth block.
+//
+// A Client obtained from the cache may outlive the lease backing its token
+// (AppRole/Kubernetes logins commonly carry TTLs well under a long batch
+// run), so Read/ReadWithData/Write detect a permission-denied response, log
+// in again and retry the call once rather than failing permanently.
+type Client struct {
+	address string
+	auth    *AuthConfig
+	key     string // cache key this Client is stored under; "" if not cacheable.
+
+	mu  sync.RWMutex
+	api *vaultapi.Client
+}
+
+// clientCache memoizes clients by address+auth, so callers that build an
+// AuthConfig fresh for every request (provider/pki and provider/ssh's Vault
+// issuer/signer configs do, since each CredentialRequest carries its own)
+// don't log in against AppRole/Kubernetes auth again for every certificate.
+// A cached Client re-authenticates itself on demand once its token's lease
+// expires -- see (*Client).do -- so entries are never evicted here.
+var (
+	clientCacheMu sync.Mutex
+	clientCache   = map[string]*Client{}
+)
+
+// NewClient creates a Client authenticated against the Vault server at
+// address using auth, reusing a cached, already-authenticated Client for the
+// same address and auth configuration when one exists. Token auth is never
+// cached, since SetToken has no login round trip to amortize.
+func NewClient(address string, auth *AuthConfig) (*Client, error) {
+	if err := auth.validate(); err != nil {
+		return nil, fmt.Errorf("vault auth: %s", err)
+	}
+
+	key := ""
+	if auth.Token == "" {
+		if k, err := cacheKey(address, auth); err == nil {
+			key = k
+		}
+	}
+	if key != "" {
+		clientCacheMu.Lock()
+		cached, found := clientCache[key]
+		clientCacheMu.Unlock()
+		if found {
+			return cached, nil
+		}
+	}
+
+	api, err := newAuthenticatedAPIClient(address, auth)
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{address: address, auth: auth, key: key, api: api}
+	if key != "" {
+		clientCacheMu.Lock()
+		clientCache[key] = client
+		clientCacheMu.Unlock()
+	}
+	return client, nil
+}
+
+// newAuthenticatedAPIClient creates a Vault API client for address and logs
+// it in using auth.
+func newAuthenticatedAPIClient(address string, auth *AuthConfig) (*vaultapi.Client, error) {
+	cfg := vaultapi.DefaultConfig()
+	cfg.Address = address
+	api, err := vaultapi.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create vault client: %s", err)
+	}
+	switch {
+	case auth.Token != "":
+		api.SetToken(auth.Token)
+	case auth.AppRole != nil:
+		if err := loginAppRole(api, auth.AppRole); err != nil {
+			return nil, err
+		}
+	case auth.Kubernetes != nil:
+		if err := loginKubernetes(api, auth.Kubernetes); err != nil {
+			return nil, err
+		}
+	}
+	return api, nil
+}
+
+// cacheKey identifies a Client by the address and auth configuration used to
+// create it.
+func cacheKey(address string, auth *AuthConfig) (string, error) {
+	b, err := json.Marshal(auth)
+	if err != nil {
+		return "", err
+	}
+	return address + "|" + string(b), nil
+}
+
+func loginAppRole(api *vaultapi.Client, a *AppRoleAuth) error {
+	mount := a.Mount
+	if mount == "" {
+		mount = "approle"
+	}
+	secret, err := api.Logical().Write(fmt.Sprintf("auth/%s/login", mount), map[string]interface{}{
+		"role_id":   a.RoleID,
+		"secret_id": a.SecretID,
+	})
+	if err != nil {
+		return fmt.Errorf("approle login: %s", err)
+	}
+	api.SetToken(secret.Auth.ClientToken)
+	return nil
+}
+
+func loginKubernetes(api *vaultapi.Client, k *KubernetesAuth) error {
+	mount := k.Mount
+	if mount == "" {
+		mount = "kubernetes"
+	}
+	path := k.JWTPath
+	if path == "" {
+		path = defaultKubernetesJWTPath
+	}
+	jwt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read service account token: %s", err)
+	}
+	secret, err := api.Logical().Write(fmt.Sprintf("auth/%s/login", mount), map[string]interface{}{
+		"role": k.Role,
+		"jwt":  string(jwt),
+	})
+	if err != nil {
+		return fmt.Errorf("kubernetes login: %s", err)
+	}
+	api.SetToken(secret.Auth.ClientToken)
+	return nil
+}
+
+// Read reads path, re-authenticating and retrying once if the cached
+// client's token has expired.
+func (c *Client) Read(path string) (*vaultapi.Secret, error) {
+	return c.do(func(api *vaultapi.Client) (*vaultapi.Secret, error) {
+		return api.Logical().Read(path)
+	})
+}
+
+// ReadWithData reads path with query parameters, re-authenticating and
+// retrying once if the cached client's token has expired.
+func (c *Client) ReadWithData(path string, data map[string][]string) (*vaultapi.Secret, error) {
+	return c.do(func(api *vaultapi.Client) (*vaultapi.Secret, error) {
+		return api.Logical().ReadWithData(path, data)
+	})
+}
+
+// Write writes data to path, re-authenticating and retrying once if the
+// cached client's token has expired.
+func (c *Client) Write(path string, data map[string]interface{}) (*vaultapi.Secret, error) {
+	return c.do(func(api *vaultapi.Client) (*vaultapi.Secret, error) {
+		return api.Logical().Write(path, data)
+	})
+}
+
+// do runs call against the Client's current underlying API client. If call
+// fails with a permission-denied response -- the symptom of a cached
+// client's AppRole/Kubernetes token lease having expired -- do logs in again,
+// swaps it into c in place (so every other holder of this cached *Client
+// picks up the refreshed token too) and retries call exactly once.
+func (c *Client) do(call func(*vaultapi.Client) (*vaultapi.Secret, error)) (*vaultapi.Secret, error) {
+	c.mu.RLock()
+	api := c.api
+	c.mu.RUnlock()
+
+	secret, err := call(api)
+	if !isPermissionDenied(err) {
+		return secret, err
+	}
+
+	fresh, loginErr := newAuthenticatedAPIClient(c.address, c.auth)
+	if loginErr != nil {
+		return nil, fmt.Errorf("re-authenticate after permission denied: %s", loginErr)
+	}
+
+	c.mu.Lock()
+	c.api = fresh
+	c.mu.Unlock()
+
+	return call(fresh)
+}
+
+// isPermissionDenied reports whether err is a Vault 403 response, the status
+// Vault returns for both a bad token and an expired one.
+func isPermissionDenied(err error) bool {
+	var respErr *vaultapi.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// IsTransient reports whether err looks like a transient Vault or network
+// condition (rate limiting, a 5xx response, a connection failure) rather
+// than an auth or validation problem. Callers use this to decide whether to
+// wrap err in a sidecred.RecoverableError.
+func IsTransient(err error) bool {
+	var respErr *vaultapi.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode == 429 || respErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
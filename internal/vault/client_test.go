@@ -0,0 +1,65 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestClientReauthenticatesOnPermissionDenied simulates a cached client whose
+// AppRole token has outlived its lease: the first read is rejected with a
+// 403, after which the client should log in again and retry successfully,
+// rather than failing permanently.
+func TestClientReauthenticatesOnPermissionDenied(t *testing.T) {
+	var logins int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/auth/approle/login":
+			logins++
+			token := map[int]string{1: "token-expired", 2: "token-fresh"}[logins]
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"auth": map[string]interface{}{"client_token": token},
+			})
+		case r.URL.Path == "/v1/secret/data/foo":
+			if r.Header.Get("X-Vault-Token") != "token-fresh" {
+				writeJSON(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"data": map[string]interface{}{"data": map[string]interface{}{"value": "secret"}},
+			})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, &AuthConfig{AppRole: &AppRoleAuth{RoleID: "role", SecretID: "secret"}})
+	if err != nil {
+		t.Fatalf("NewClient() = %s", err)
+	}
+
+	secret, err := client.Read("secret/data/foo")
+	if err != nil {
+		t.Fatalf("Read() = %s", err)
+	}
+	if secret == nil {
+		t.Fatal("Read() returned nil secret")
+	}
+	if logins != 2 {
+		t.Fatalf("logins = %d, want 2 (initial login + re-authentication after 403)", logins)
+	}
+}
+
+func TestNewClientRejectsNilAuth(t *testing.T) {
+	if _, err := NewClient("http://vault.invalid", nil); err == nil {
+		t.Fatal("NewClient() = nil error, want error for a nil AuthConfig")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,121 @@
+package sidecred
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// ApplyFunc performs the provider-create/store-write work for a single
+// CredentialsMap. Process is independent of the concrete provider/store
+// wiring, which is constructed by the caller (typically the CLI entrypoint)
+// and closed over here.
+type ApplyFunc func(*CredentialsMap) error
+
+// Logger is the subset of *log.Logger Process uses to report retries. It's
+// satisfied by the standard library logger, so callers can pass one straight
+// through.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// nopLogger discards everything, so ProcessOptions.Logger never has to be
+// nil-checked at the call site.
+type nopLogger struct{}
+
+func (nopLogger) Printf(format string, args ...interface{}) {}
+
+// ProcessOptions configures Process.
+type ProcessOptions struct {
+	// FailFast aborts the entire run on the first error, recoverable or not,
+	// without retrying. This preserves the original all-or-nothing behavior.
+	// No command-line flag sets it; callers of Process set it directly.
+	FailFast bool
+
+	// MaxRetries bounds the number of retries Process attempts for a
+	// RecoverableError before giving up on that CredentialsMap entry.
+	MaxRetries int
+
+	// Backoff is the base delay between retries of a RecoverableError; the
+	// actual delay grows exponentially with each attempt and is jittered.
+	Backoff time.Duration
+
+	// Logger receives a line for every retried RecoverableError. Defaults to
+	// a no-op logger when left nil.
+	Logger Logger
+}
+
+// DefaultProcessOptions used when Process is called with nil options.
+func DefaultProcessOptions() *ProcessOptions {
+	return &ProcessOptions{MaxRetries: 3, Backoff: time.Second, Logger: nopLogger{}}
+}
+
+// Process runs apply for every CredentialsMap returned by cfg.Requests(). A
+// RecoverableError returned by apply is retried with exponential backoff and
+// jitter and does not abort sibling entries. Any other error aborts only the
+// entry that produced it. Once every entry has been attempted, the errors
+// from entries that never recovered are aggregated and returned together. If
+// opts.FailFast is set, Process instead returns on the first error of either
+// kind, matching the pre-RecoverableError behavior.
+//
+// cfg.Validate() is checked before cfg.Requests() is read, since Config
+// implementations that resolve lazily (e.g. config.v2, which merges includes
+// and renders templates) return a zero-value Requests() on a resolution
+// failure -- without this check that would look like a legitimately empty
+// config and Process would report success after doing nothing.
+func Process(cfg Config, apply ApplyFunc, opts *ProcessOptions) error {
+	if opts == nil {
+		opts = DefaultProcessOptions()
+	}
+	if opts.Logger == nil {
+		opts.Logger = nopLogger{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %s", err)
+	}
+	requests := cfg.Requests()
+	var failed []string
+	for _, entry := range requests {
+		if err := processEntry(entry, apply, opts); err != nil {
+			if opts.FailFast {
+				return err
+			}
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d requests failed:\n%s", len(failed), len(requests), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// processEntry applies entry, retrying RecoverableErrors up to
+// opts.MaxRetries times with exponential backoff and jitter. With
+// opts.FailFast set, the first error of either kind is returned unretried.
+func processEntry(entry *CredentialsMap, apply ApplyFunc, opts *ProcessOptions) error {
+	var err error
+	for attempt := 0; attempt <= opts.MaxRetries; attempt++ {
+		if err = apply(entry); err == nil {
+			return nil
+		}
+		if !IsRecoverable(err) || opts.FailFast {
+			return fmt.Errorf("store %q: %s", entry.Store, err)
+		}
+		if attempt == opts.MaxRetries {
+			break
+		}
+		delay := backoff(opts.Backoff, attempt)
+		opts.Logger.Printf("store %q: recoverable error (attempt %d/%d), retrying in %s: %s",
+			entry.Store, attempt+1, opts.MaxRetries+1, delay, err)
+		time.Sleep(delay)
+	}
+	return fmt.Errorf("store %q: giving up after %d attempts: %s", entry.Store, opts.MaxRetries+1, err)
+}
+
+// backoff returns an exponentially increasing delay with up to base worth of
+// jitter added, so retries across many entries don't all land at once.
+func backoff(base time.Duration, attempt int) time.Duration {
+	delay := base * time.Duration(1<<uint(attempt))
+	return delay + time.Duration(rand.Int63n(int64(base)+1))
+}
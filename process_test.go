@@ -0,0 +1,170 @@
+package sidecred
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeConfig returns a fixed set of CredentialsMap entries from Requests, so
+// Process can be exercised without a real config.Config implementation.
+type fakeConfig struct {
+	namespace   string
+	stores      []*StoreConfig
+	requests    []*CredentialsMap
+	validateErr error
+}
+
+func (f *fakeConfig) Namespace() string           { return f.namespace }
+func (f *fakeConfig) Stores() []*StoreConfig      { return f.stores }
+func (f *fakeConfig) Requests() []*CredentialsMap { return f.requests }
+func (f *fakeConfig) Validate() error             { return f.validateErr }
+
+func TestProcessRetriesRecoverableErrors(t *testing.T) {
+	cfg := &fakeConfig{requests: []*CredentialsMap{{Store: "store-a"}}}
+
+	var attempts int
+	apply := func(entry *CredentialsMap) error {
+		attempts++
+		if attempts < 3 {
+			return NewRecoverableError(errors.New("try again"))
+		}
+		return nil
+	}
+
+	err := Process(cfg, apply, &ProcessOptions{MaxRetries: 3, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Process() = %v, want nil", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestProcessGivesUpAfterMaxRetries(t *testing.T) {
+	cfg := &fakeConfig{requests: []*CredentialsMap{{Store: "store-a"}}}
+
+	var attempts int
+	apply := func(entry *CredentialsMap) error {
+		attempts++
+		return NewRecoverableError(errors.New("still failing"))
+	}
+
+	err := Process(cfg, apply, &ProcessOptions{MaxRetries: 2, Backoff: time.Millisecond})
+	if err == nil {
+		t.Fatal("Process() = nil, want error")
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3 (1 initial + 2 retries)", attempts)
+	}
+}
+
+func TestProcessDoesNotRetryUnrecoverableErrors(t *testing.T) {
+	cfg := &fakeConfig{requests: []*CredentialsMap{{Store: "store-a"}}}
+
+	var attempts int
+	apply := func(entry *CredentialsMap) error {
+		attempts++
+		return errors.New("permanent failure")
+	}
+
+	err := Process(cfg, apply, &ProcessOptions{MaxRetries: 3, Backoff: time.Millisecond})
+	if err == nil {
+		t.Fatal("Process() = nil, want error")
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestProcessFailFastAbortsOnFirstError(t *testing.T) {
+	cfg := &fakeConfig{requests: []*CredentialsMap{{Store: "store-a"}, {Store: "store-b"}}}
+
+	var processed []string
+	apply := func(entry *CredentialsMap) error {
+		processed = append(processed, entry.Store)
+		return errors.New("boom")
+	}
+
+	err := Process(cfg, apply, &ProcessOptions{FailFast: true, MaxRetries: 3, Backoff: time.Millisecond})
+	if err == nil {
+		t.Fatal("Process() = nil, want error")
+	}
+	if len(processed) != 1 {
+		t.Fatalf("processed %d entries, want 1 (FailFast should stop after the first)", len(processed))
+	}
+}
+
+func TestProcessAggregatesFailuresWithoutFailFast(t *testing.T) {
+	cfg := &fakeConfig{requests: []*CredentialsMap{{Store: "store-a"}, {Store: "store-b"}}}
+
+	var processed int
+	apply := func(entry *CredentialsMap) error {
+		processed++
+		return errors.New("boom")
+	}
+
+	err := Process(cfg, apply, &ProcessOptions{MaxRetries: 0, Backoff: time.Millisecond})
+	if err == nil {
+		t.Fatal("Process() = nil, want error")
+	}
+	if processed != 2 {
+		t.Fatalf("processed %d entries, want 2 (a failure should not abort sibling entries)", processed)
+	}
+	if !strings.HasPrefix(err.Error(), "2 of 2 requests failed") {
+		t.Fatalf("Process() = %q, want both failures aggregated", err)
+	}
+}
+
+func TestProcessRejectsInvalidConfigWithoutCallingApply(t *testing.T) {
+	cfg := &fakeConfig{
+		requests:    []*CredentialsMap{{Store: "store-a"}},
+		validateErr: errors.New("bad discover block"),
+	}
+
+	called := false
+	apply := func(entry *CredentialsMap) error {
+		called = true
+		return nil
+	}
+
+	err := Process(cfg, apply, &ProcessOptions{MaxRetries: 0, Backoff: time.Millisecond})
+	if err == nil {
+		t.Fatal("Process() = nil, want error")
+	}
+	if called {
+		t.Fatal("apply was called for a config that failed Validate()")
+	}
+}
+
+// recordingLogger collects the lines Process logs, so tests can assert a
+// retry was actually reported.
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...interface{}) {
+	l.lines = append(l.lines, format)
+}
+
+func TestProcessLogsRetries(t *testing.T) {
+	cfg := &fakeConfig{requests: []*CredentialsMap{{Store: "store-a"}}}
+	logger := &recordingLogger{}
+
+	var attempts int
+	apply := func(entry *CredentialsMap) error {
+		attempts++
+		if attempts < 2 {
+			return NewRecoverableError(errors.New("try again"))
+		}
+		return nil
+	}
+
+	if err := Process(cfg, apply, &ProcessOptions{MaxRetries: 3, Backoff: time.Millisecond, Logger: logger}); err != nil {
+		t.Fatalf("Process() = %v, want nil", err)
+	}
+	if len(logger.lines) != 1 {
+		t.Fatalf("logged %d lines, want 1", len(logger.lines))
+	}
+}
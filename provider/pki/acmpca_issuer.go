@@ -0,0 +1,93 @@
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/acmpca"
+
+	"github.com/telia-oss/sidecred"
+)
+
+// ACMPCAIssuerConfig signs certificates using an AWS ACM Private CA.
+type ACMPCAIssuerConfig struct {
+	Region                  string `json:"region"`
+	CertificateAuthorityARN string `json:"certificate_authority_arn"`
+	SigningAlgorithm        string `json:"signing_algorithm"`
+
+	// TemplateARN selects an ACM PCA certificate template. Defaults to the
+	// PCA's standard end-entity template when empty.
+	TemplateARN string `json:"template_arn,omitempty"`
+
+	// MaxLifetime bounds the TTL a requester may ask for. Left unset, no
+	// client-side limit is enforced and the CA's own validity period applies.
+	MaxLifetime time.Duration `json:"max_ttl,omitempty"`
+}
+
+// MaxTTL implements Issuer.
+func (c *ACMPCAIssuerConfig) MaxTTL() (time.Duration, error) {
+	if c.CertificateAuthorityARN == "" || c.SigningAlgorithm == "" {
+		return 0, fmt.Errorf("%q and %q must be defined", "certificate_authority_arn", "signing_algorithm")
+	}
+	return c.MaxLifetime, nil
+}
+
+// Sign implements Issuer.
+func (c *ACMPCAIssuerConfig) Sign(csr *x509.CertificateRequest, ttl time.Duration) ([]byte, []byte, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(c.Region)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("create aws session: %s", err)
+	}
+	client := acmpca.New(sess)
+
+	issued, err := client.IssueCertificate(&acmpca.IssueCertificateInput{
+		CertificateAuthorityArn: aws.String(c.CertificateAuthorityARN),
+		Csr:                     csrPEM(csr),
+		SigningAlgorithm:        aws.String(c.SigningAlgorithm),
+		TemplateArn:             optionalString(c.TemplateARN),
+		Validity: &acmpca.Validity{
+			Type:  aws.String("SECONDS"),
+			Value: aws.Int64(int64(ttl.Seconds())),
+		},
+	})
+	if err != nil {
+		return nil, nil, wrapAWSError("issue certificate", err)
+	}
+
+	getInput := &acmpca.GetCertificateInput{
+		CertificateAuthorityArn: aws.String(c.CertificateAuthorityARN),
+		CertificateArn:          issued.CertificateArn,
+	}
+	if err := client.WaitUntilCertificateIssued(getInput); err != nil {
+		return nil, nil, wrapAWSError("wait for certificate", err)
+	}
+	cert, err := client.GetCertificate(getInput)
+	if err != nil {
+		return nil, nil, wrapAWSError("get certificate", err)
+	}
+	return []byte(aws.StringValue(cert.Certificate)), []byte(aws.StringValue(cert.CertificateChain)), nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+// wrapAWSError wraps a transient ACM PCA error (throttling, 5xx) in a
+// sidecred.RecoverableError, leaving everything else (bad ARNs, denied
+// requests) bare.
+func wrapAWSError(action string, err error) error {
+	wrapped := fmt.Errorf("%s: %s", action, err)
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		if reqErr.StatusCode() == 429 || reqErr.StatusCode() >= 500 {
+			return sidecred.NewRecoverableError(wrapped)
+		}
+	}
+	return wrapped
+}
@@ -0,0 +1,90 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"time"
+)
+
+// LocalIssuerConfig signs certificates using a CA key and certificate stored
+// on disk, for environments without Vault or ACM Private CA.
+type LocalIssuerConfig struct {
+	CACertPath string `json:"ca_cert_path"`
+	CAKeyPath  string `json:"ca_key_path"`
+}
+
+// MaxTTL implements Issuer. A locally signed certificate can never outlive
+// its issuing CA, so the maximum is the CA's remaining validity.
+func (c *LocalIssuerConfig) MaxTTL() (time.Duration, error) {
+	ca, _, err := c.load()
+	if err != nil {
+		return 0, err
+	}
+	return time.Until(ca.NotAfter), nil
+}
+
+// Sign implements Issuer.
+func (c *LocalIssuerConfig) Sign(csr *x509.CertificateRequest, ttl time.Duration) ([]byte, []byte, error) {
+	ca, key, err := c.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate serial: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		IPAddresses:  csr.IPAddresses,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(ttl),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, csr.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sign certificate: %s", err)
+	}
+	leaf := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+	return leaf, chain, nil
+}
+
+// load reads and parses the CA certificate and key from disk.
+func (c *LocalIssuerConfig) load() (*x509.Certificate, crypto.Signer, error) {
+	if c.CACertPath == "" || c.CAKeyPath == "" {
+		return nil, nil, fmt.Errorf("%q and %q must be defined", "ca_cert_path", "ca_key_path")
+	}
+	certPEM, err := ioutil.ReadFile(c.CACertPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read ca certificate: %s", err)
+	}
+	ca, err := parsePEMCertificate(certPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse ca certificate: %s", err)
+	}
+	keyPEM, err := ioutil.ReadFile(c.CAKeyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read ca key: %s", err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("parse ca key: no PEM data found")
+	}
+	raw, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse ca key: %s", err)
+	}
+	key, ok := raw.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("ca key does not support signing")
+	}
+	return ca, key, nil
+}
@@ -0,0 +1,200 @@
+// Package pki implements a sidecred.Provider that issues X.509 certificates
+// by generating a key and CSR locally and submitting it to a configurable
+// issuer backend (Vault PKI, AWS ACM Private CA, or a local CA).
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/telia-oss/sidecred"
+)
+
+func init() {
+	sidecred.RegisterProvider(sidecred.ProviderRegistration{
+		Type:        sidecred.X509Certificate,
+		NewConfig:   func() sidecred.Validatable { return &RequestConfig{} },
+		New:         func(json.RawMessage) (sidecred.Provider, error) { return New(), nil },
+		Description: "Issues X.509 certificates from a Vault PKI mount, AWS ACM Private CA, or a local CA.",
+	})
+}
+
+// KeyType selects the private key algorithm generated for the certificate.
+type KeyType string
+
+// Supported key types.
+const (
+	RSA     KeyType = "rsa"
+	ECDSA   KeyType = "ecdsa"
+	Ed25519 KeyType = "ed25519"
+)
+
+// Issuer signs a CSR built by the provider and reports the longest TTL it is
+// willing to issue for.
+type Issuer interface {
+	MaxTTL() (time.Duration, error)
+	Sign(csr *x509.CertificateRequest, ttl time.Duration) (leafPEM, chainPEM []byte, err error)
+}
+
+// IssuerConfig selects which CA backend signs the certificate. Exactly one
+// field must be set.
+type IssuerConfig struct {
+	Vault  *VaultIssuerConfig  `json:"vault,omitempty"`
+	ACMPCA *ACMPCAIssuerConfig `json:"acmpca,omitempty"`
+	Local  *LocalIssuerConfig  `json:"local,omitempty"`
+}
+
+// resolve returns the single configured Issuer, or an error if zero or more
+// than one backend is set.
+func (c *IssuerConfig) resolve() (Issuer, error) {
+	var issuers []Issuer
+	if c.Vault != nil {
+		issuers = append(issuers, c.Vault)
+	}
+	if c.ACMPCA != nil {
+		issuers = append(issuers, c.ACMPCA)
+	}
+	if c.Local != nil {
+		issuers = append(issuers, c.Local)
+	}
+	switch len(issuers) {
+	case 0:
+		return nil, fmt.Errorf("exactly one of %q, %q or %q must be set", "vault", "acmpca", "local")
+	case 1:
+		return issuers[0], nil
+	default:
+		return nil, fmt.Errorf("only one of %q, %q or %q may be set", "vault", "acmpca", "local")
+	}
+}
+
+// RequestConfig for the PKI provider.
+type RequestConfig struct {
+	// CommonName is the certificate's subject CN, and may be a wildcard
+	// (e.g. "*.example.com").
+	CommonName string `json:"common_name"`
+
+	// AltNames are additional DNS SANs.
+	AltNames []string `json:"alt_names,omitempty"`
+
+	// IPSANs are IP address SANs. Not allowed when CommonName is a wildcard.
+	IPSANs []string `json:"ip_sans,omitempty"`
+
+	// TTL the certificate is requested for.
+	TTL time.Duration `json:"ttl"`
+
+	// KeyType of the generated private key.
+	KeyType KeyType `json:"key_type"`
+
+	// KeyBits is the RSA key size. Defaults to 2048 and is ignored for
+	// ecdsa/ed25519.
+	KeyBits int `json:"key_bits,omitempty"`
+
+	// Issuer selects the CA backend that signs the certificate.
+	Issuer *IssuerConfig `json:"issuer"`
+}
+
+// Validate implements sidecred.Validatable.
+func (c *RequestConfig) Validate() error {
+	if c.CommonName == "" {
+		return fmt.Errorf("%q must be defined", "common_name")
+	}
+	if strings.HasPrefix(c.CommonName, "*.") && len(c.IPSANs) > 0 {
+		return fmt.Errorf("%q must be empty when %q is a wildcard", "ip_sans", "common_name")
+	}
+	for _, ip := range c.IPSANs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("%q: invalid IP %q", "ip_sans", ip)
+		}
+	}
+	if c.TTL <= 0 {
+		return fmt.Errorf("%q must be greater than zero", "ttl")
+	}
+	switch c.KeyType {
+	case RSA, ECDSA, Ed25519:
+	case "":
+		return fmt.Errorf("%q must be defined", "key_type")
+	default:
+		return fmt.Errorf("%q: unknown key type %q", "key_type", string(c.KeyType))
+	}
+	if c.Issuer == nil {
+		return fmt.Errorf("%q must be defined", "issuer")
+	}
+	issuer, err := c.Issuer.resolve()
+	if err != nil {
+		return fmt.Errorf("%q: %s", "issuer", err)
+	}
+	max, err := issuer.MaxTTL()
+	if err != nil {
+		return fmt.Errorf("%q: %s", "issuer", err)
+	}
+	if max > 0 && c.TTL > max {
+		return fmt.Errorf("%q (%s) exceeds issuer maximum (%s)", "ttl", c.TTL, max)
+	}
+	return nil
+}
+
+// Provider issues sidecred.X509Certificate credentials: the signed leaf
+// certificate, its chain, and the private key, as three separate credentials
+// that the existing stores can persist.
+type Provider struct{}
+
+// New PKI provider.
+func New() *Provider {
+	return &Provider{}
+}
+
+// Type implements sidecred.Provider.
+func (p *Provider) Type() sidecred.CredentialType {
+	return sidecred.X509Certificate
+}
+
+// Create implements sidecred.Provider.
+func (p *Provider) Create(request *sidecred.CredentialRequest) ([]*sidecred.Credential, error) {
+	c := &RequestConfig{}
+	if err := sidecred.UnmarshalConfig(request.Config, c); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %s", err)
+	}
+	issuer, err := c.Issuer.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := generateKey(c.KeyType, c.KeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %s", err)
+	}
+	csr, err := buildCSR(c, key)
+	if err != nil {
+		return nil, fmt.Errorf("build csr: %s", err)
+	}
+
+	leafPEM, chainPEM, err := issuer.Sign(csr, c.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("sign certificate: %s", err)
+	}
+	leaf, err := parsePEMCertificate(leafPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parse issued certificate: %s", err)
+	}
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("encode private key: %s", err)
+	}
+
+	rotationWindow := request.RotationWindow
+	if rotationWindow == 0 {
+		// Renew once two thirds of the certificate's lifetime has elapsed,
+		// i.e. when a third of its remaining lifetime is left.
+		rotationWindow = time.Until(leaf.NotAfter) / 3
+	}
+
+	return []*sidecred.Credential{
+		{Name: request.Name + "-certificate", Value: string(leafPEM), Expiration: leaf.NotAfter, RotationWindow: rotationWindow},
+		{Name: request.Name + "-chain", Value: string(chainPEM), Expiration: leaf.NotAfter, RotationWindow: rotationWindow},
+		{Name: request.Name + "-private-key", Value: string(keyPEM), Expiration: leaf.NotAfter, RotationWindow: rotationWindow},
+	}, nil
+}
@@ -0,0 +1,150 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/telia-oss/sidecred"
+)
+
+func TestRequestConfigValidate(t *testing.T) {
+	local := &IssuerConfig{Local: &LocalIssuerConfig{CACertPath: "/dev/null", CAKeyPath: "/dev/null"}}
+	tests := []struct {
+		name    string
+		config  *RequestConfig
+		wantErr bool
+	}{
+		{
+			name:    "wildcard with ip sans rejected",
+			config:  &RequestConfig{CommonName: "*.example.com", IPSANs: []string{"10.0.0.1"}, TTL: time.Hour, KeyType: RSA, Issuer: local},
+			wantErr: true,
+		},
+		{
+			name:    "invalid ip san",
+			config:  &RequestConfig{CommonName: "example.com", IPSANs: []string{"not-an-ip"}, TTL: time.Hour, KeyType: RSA, Issuer: local},
+			wantErr: true,
+		},
+		{
+			name:    "missing common name",
+			config:  &RequestConfig{TTL: time.Hour, KeyType: RSA, Issuer: local},
+			wantErr: true,
+		},
+		{
+			name:    "ttl must be positive",
+			config:  &RequestConfig{CommonName: "example.com", KeyType: RSA, Issuer: local},
+			wantErr: true,
+		},
+		{
+			name:    "unknown key type",
+			config:  &RequestConfig{CommonName: "example.com", TTL: time.Hour, KeyType: "dsa", Issuer: local},
+			wantErr: true,
+		},
+		{
+			name:    "no issuer backend configured",
+			config:  &RequestConfig{CommonName: "example.com", TTL: time.Hour, KeyType: RSA, Issuer: &IssuerConfig{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderCreateWithLocalIssuer(t *testing.T) {
+	dir := t.TempDir()
+	caCertPath, caKeyPath := writeTestCA(t, dir)
+
+	p := New()
+	request := &sidecred.CredentialRequest{
+		Name: "test",
+		Config: mustMarshal(t, &RequestConfig{
+			CommonName: "example.com",
+			TTL:        time.Hour,
+			KeyType:    ECDSA,
+			Issuer: &IssuerConfig{
+				Local: &LocalIssuerConfig{CACertPath: caCertPath, CAKeyPath: caKeyPath},
+			},
+		}),
+	}
+
+	credentials, err := p.Create(request)
+	if err != nil {
+		t.Fatalf("Create() = %s", err)
+	}
+	if len(credentials) != 3 {
+		t.Fatalf("got %d credentials, want 3 (certificate, chain, private key)", len(credentials))
+	}
+
+	leafPEM := []byte(credentials[0].Value)
+	block, _ := pem.Decode(leafPEM)
+	if block == nil {
+		t.Fatal("leaf certificate is not valid PEM")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse leaf certificate: %s", err)
+	}
+	if leaf.Subject.CommonName != "example.com" {
+		t.Fatalf("CommonName = %q, want %q", leaf.Subject.CommonName, "example.com")
+	}
+}
+
+// writeTestCA generates a self-signed CA certificate and key in dir and
+// returns their paths, for exercising LocalIssuerConfig without Vault or AWS.
+func writeTestCA(t *testing.T, dir string) (certPath, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ca key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create ca certificate: %s", err)
+	}
+	certPath = filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatalf("write ca certificate: %s", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal ca key: %s", err)
+	}
+	keyPath = filepath.Join(dir, "ca-key.pem")
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o644); err != nil {
+		t.Fatalf("write ca key: %s", err)
+	}
+	return certPath, keyPath
+}
+
+func mustMarshal(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+	return b
+}
@@ -0,0 +1,57 @@
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/telia-oss/sidecred"
+	vaultinternal "github.com/telia-oss/sidecred/internal/vault"
+)
+
+// VaultIssuerConfig signs certificates using a Vault PKI secrets engine's
+// sign-verbatim endpoint.
+type VaultIssuerConfig struct {
+	Address string                    `json:"address"`
+	Auth    *vaultinternal.AuthConfig `json:"auth"`
+	Mount   string                    `json:"mount"`
+	Role    string                    `json:"role"`
+
+	// MaxLifetime bounds the TTL a requester may ask for. Left unset, no
+	// limit is enforced client-side and Vault's own role TTL applies.
+	MaxLifetime time.Duration `json:"max_ttl,omitempty"`
+}
+
+// MaxTTL implements Issuer.
+func (c *VaultIssuerConfig) MaxTTL() (time.Duration, error) {
+	if c.Mount == "" || c.Role == "" {
+		return 0, fmt.Errorf("%q and %q must be defined", "mount", "role")
+	}
+	return c.MaxLifetime, nil
+}
+
+// Sign implements Issuer.
+func (c *VaultIssuerConfig) Sign(csr *x509.CertificateRequest, ttl time.Duration) ([]byte, []byte, error) {
+	client, err := vaultinternal.NewClient(c.Address, c.Auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	path := fmt.Sprintf("%s/sign-verbatim/%s", c.Mount, c.Role)
+	secret, err := client.Write(path, map[string]interface{}{
+		"csr": string(csrPEM(csr)),
+		"ttl": ttl.String(),
+	})
+	if err != nil {
+		wrapped := fmt.Errorf("sign-verbatim: %s", err)
+		if vaultinternal.IsTransient(err) {
+			return nil, nil, sidecred.NewRecoverableError(wrapped)
+		}
+		return nil, nil, wrapped
+	}
+	if secret == nil {
+		return nil, nil, fmt.Errorf("sign-verbatim: empty response")
+	}
+	leaf, _ := secret.Data["certificate"].(string)
+	chain, _ := secret.Data["ca_chain"].(string)
+	return []byte(leaf), []byte(chain), nil
+}
@@ -0,0 +1,75 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// LocalSignerConfig signs certificates using an SSH CA private key stored on
+// disk, for environments without a Vault SSH secrets engine.
+type LocalSignerConfig struct {
+	CAKeyPath string `json:"ca_key_path"`
+}
+
+// MaxTTL implements Signer. No client-side maximum is enforced; the CA key
+// itself does not carry a validity period.
+func (c *LocalSignerConfig) MaxTTL() (time.Duration, error) {
+	if c.CAKeyPath == "" {
+		return 0, fmt.Errorf("%q must be defined", "ca_key_path")
+	}
+	return 0, nil
+}
+
+// Sign implements Signer.
+func (c *LocalSignerConfig) Sign(pub ssh.PublicKey, req *RequestConfig) ([]byte, time.Time, error) {
+	keyPEM, err := ioutil.ReadFile(c.CAKeyPath)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("read ca key: %s", err)
+	}
+	ca, err := ssh.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("parse ca key: %s", err)
+	}
+
+	serial, err := randomUint64()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("generate serial: %s", err)
+	}
+
+	certType := uint32(ssh.UserCert)
+	if req.CertType == HostCert {
+		certType = ssh.HostCert
+	}
+	now := time.Now()
+	expiration := now.Add(req.TTL)
+	cert := &ssh.Certificate{
+		Key:             pub,
+		Serial:          serial,
+		CertType:        certType,
+		KeyId:           req.KeyID,
+		ValidPrincipals: req.Principals,
+		ValidAfter:      uint64(now.Unix()),
+		ValidBefore:     uint64(expiration.Unix()),
+		Permissions: ssh.Permissions{
+			Extensions:      req.Extensions,
+			CriticalOptions: req.CriticalOptions,
+		},
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		return nil, time.Time{}, fmt.Errorf("sign certificate: %s", err)
+	}
+	return ssh.MarshalAuthorizedKey(cert), expiration, nil
+}
+
+func randomUint64() (uint64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
@@ -0,0 +1,208 @@
+// Package ssh implements a sidecred.Provider that signs SSH user/host
+// certificates, either for an ephemeral generated key or an externally
+// supplied public key.
+package ssh
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/telia-oss/sidecred"
+)
+
+func init() {
+	sidecred.RegisterProvider(sidecred.ProviderRegistration{
+		Type:        sidecred.SSHCertificate,
+		NewConfig:   func() sidecred.Validatable { return &RequestConfig{} },
+		New:         func(json.RawMessage) (sidecred.Provider, error) { return New(), nil },
+		Description: "Signs SSH user/host certificates using a Vault SSH secrets engine or a local CA key.",
+	})
+}
+
+// CertType identifies whether the certificate authenticates a user or a host.
+type CertType string
+
+// Supported certificate types.
+const (
+	UserCert CertType = "user"
+	HostCert CertType = "host"
+)
+
+// Signer signs a public key into an SSH certificate and reports the longest
+// TTL it is willing to issue for.
+type Signer interface {
+	MaxTTL() (time.Duration, error)
+	Sign(pub ssh.PublicKey, c *RequestConfig) (certAuthorizedKey []byte, expiration time.Time, err error)
+}
+
+// SignerConfig selects which CA backend signs the certificate. Exactly one
+// field must be set.
+type SignerConfig struct {
+	Vault *VaultSignerConfig `json:"vault,omitempty"`
+	Local *LocalSignerConfig `json:"local,omitempty"`
+}
+
+// resolve returns the single configured Signer, or an error if zero or more
+// than one backend is set.
+func (c *SignerConfig) resolve() (Signer, error) {
+	var signers []Signer
+	if c.Vault != nil {
+		signers = append(signers, c.Vault)
+	}
+	if c.Local != nil {
+		signers = append(signers, c.Local)
+	}
+	switch len(signers) {
+	case 0:
+		return nil, fmt.Errorf("exactly one of %q or %q must be set", "vault", "local")
+	case 1:
+		return signers[0], nil
+	default:
+		return nil, fmt.Errorf("only one of %q or %q may be set", "vault", "local")
+	}
+}
+
+// RequestConfig for the SSH provider.
+type RequestConfig struct {
+	// KeyID is recorded in the certificate and typically shown in audit logs.
+	KeyID string `json:"key_id"`
+
+	// Principals the certificate is valid for. Must be non-empty.
+	Principals []string `json:"principals"`
+
+	// CertType is "user" or "host".
+	CertType CertType `json:"cert_type"`
+
+	// TTL the certificate is requested for.
+	TTL time.Duration `json:"ttl"`
+
+	// Extensions are only valid on user certificates (e.g. "permit-pty").
+	Extensions map[string]string `json:"extensions,omitempty"`
+
+	// CriticalOptions restrict how the certificate may be used.
+	CriticalOptions map[string]string `json:"critical_options,omitempty"`
+
+	// PublicKey, when set, is an externally supplied authorized_keys-format
+	// public key to sign instead of generating an ephemeral keypair.
+	PublicKey string `json:"public_key,omitempty"`
+
+	// Signer selects the CA backend that signs the certificate.
+	Signer *SignerConfig `json:"signer"`
+}
+
+// Validate implements sidecred.Validatable.
+func (c *RequestConfig) Validate() error {
+	if len(c.Principals) == 0 {
+		return fmt.Errorf("%q must be defined", "principals")
+	}
+	switch c.CertType {
+	case UserCert, HostCert:
+	case "":
+		return fmt.Errorf("%q must be defined", "cert_type")
+	default:
+		return fmt.Errorf("%q: unknown cert type %q", "cert_type", string(c.CertType))
+	}
+	if c.CertType == HostCert && len(c.Extensions) > 0 {
+		return fmt.Errorf("%q are only valid for %q certificates", "extensions", "user")
+	}
+	if c.TTL <= 0 {
+		return fmt.Errorf("%q must be greater than zero", "ttl")
+	}
+	if c.Signer == nil {
+		return fmt.Errorf("%q must be defined", "signer")
+	}
+	signer, err := c.Signer.resolve()
+	if err != nil {
+		return fmt.Errorf("%q: %s", "signer", err)
+	}
+	max, err := signer.MaxTTL()
+	if err != nil {
+		return fmt.Errorf("%q: %s", "signer", err)
+	}
+	if max > 0 && c.TTL > max {
+		return fmt.Errorf("%q (%s) exceeds signer maximum (%s)", "ttl", c.TTL, max)
+	}
+	return nil
+}
+
+// Provider issues sidecred.SSHCertificate credentials.
+type Provider struct{}
+
+// New SSH provider.
+func New() *Provider {
+	return &Provider{}
+}
+
+// Type implements sidecred.Provider.
+func (p *Provider) Type() sidecred.CredentialType {
+	return sidecred.SSHCertificate
+}
+
+// Create implements sidecred.Provider.
+func (p *Provider) Create(request *sidecred.CredentialRequest) ([]*sidecred.Credential, error) {
+	c := &RequestConfig{}
+	if err := sidecred.UnmarshalConfig(request.Config, c); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %s", err)
+	}
+	signer, err := c.Signer.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	pub, privPEM, err := resolvePublicKey(c.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	certAuthorizedKey, expiration, err := signer.Sign(pub, c)
+	if err != nil {
+		return nil, fmt.Errorf("sign certificate: %s", err)
+	}
+
+	rotationWindow := request.RotationWindow
+	if rotationWindow == 0 {
+		rotationWindow = time.Until(expiration) / 2
+	}
+
+	credentials := []*sidecred.Credential{
+		{Name: request.Name + "-certificate", Value: string(certAuthorizedKey), Expiration: expiration, RotationWindow: rotationWindow},
+	}
+	if privPEM != nil {
+		credentials = append(credentials, &sidecred.Credential{
+			Name: request.Name + "-private-key", Value: string(privPEM), Expiration: expiration, RotationWindow: rotationWindow,
+		})
+	}
+	return credentials, nil
+}
+
+// resolvePublicKey parses an externally supplied authorized_keys-format
+// public key, or generates an ephemeral RSA keypair when none is supplied.
+func resolvePublicKey(authorizedKey string) (ssh.PublicKey, []byte, error) {
+	if authorizedKey != "" {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse public_key: %s", err)
+		}
+		return pub, nil, nil
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate key: %s", err)
+	}
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("derive public key: %s", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode private key: %s", err)
+	}
+	return pub, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
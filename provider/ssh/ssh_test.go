@@ -0,0 +1,128 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/telia-oss/sidecred"
+)
+
+func TestRequestConfigValidate(t *testing.T) {
+	local := &SignerConfig{Local: &LocalSignerConfig{CAKeyPath: "/dev/null"}}
+	tests := []struct {
+		name    string
+		config  *RequestConfig
+		wantErr bool
+	}{
+		{
+			name:    "missing principals",
+			config:  &RequestConfig{CertType: UserCert, TTL: time.Hour, Signer: local},
+			wantErr: true,
+		},
+		{
+			name:    "unknown cert type",
+			config:  &RequestConfig{Principals: []string{"ubuntu"}, CertType: "robot", TTL: time.Hour, Signer: local},
+			wantErr: true,
+		},
+		{
+			name: "extensions only valid for user certs",
+			config: &RequestConfig{
+				Principals: []string{"ubuntu"}, CertType: HostCert, TTL: time.Hour,
+				Extensions: map[string]string{"permit-pty": ""}, Signer: local,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "ttl must be positive",
+			config:  &RequestConfig{Principals: []string{"ubuntu"}, CertType: UserCert, Signer: local},
+			wantErr: true,
+		},
+		{
+			name:    "no signer backend configured",
+			config:  &RequestConfig{Principals: []string{"ubuntu"}, CertType: UserCert, TTL: time.Hour, Signer: &SignerConfig{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderCreateWithLocalSigner(t *testing.T) {
+	dir := t.TempDir()
+	caKeyPath := writeTestCAKey(t, dir)
+
+	p := New()
+	request := &sidecred.CredentialRequest{
+		Name: "test",
+		Config: mustMarshal(t, &RequestConfig{
+			KeyID:      "test",
+			Principals: []string{"ubuntu"},
+			CertType:   UserCert,
+			TTL:        time.Hour,
+			Signer:     &SignerConfig{Local: &LocalSignerConfig{CAKeyPath: caKeyPath}},
+		}),
+	}
+
+	credentials, err := p.Create(request)
+	if err != nil {
+		t.Fatalf("Create() = %s", err)
+	}
+	if len(credentials) != 2 {
+		t.Fatalf("got %d credentials, want 2 (certificate, generated private key)", len(credentials))
+	}
+
+	pub, _, _, _, err := gossh.ParseAuthorizedKey([]byte(credentials[0].Value))
+	if err != nil {
+		t.Fatalf("parse signed certificate: %s", err)
+	}
+	cert, ok := pub.(*gossh.Certificate)
+	if !ok {
+		t.Fatal("signed key is not a certificate")
+	}
+	if cert.KeyId != "test" {
+		t.Fatalf("KeyId = %q, want %q", cert.KeyId, "test")
+	}
+	if len(cert.ValidPrincipals) != 1 || cert.ValidPrincipals[0] != "ubuntu" {
+		t.Fatalf("ValidPrincipals = %v, want [ubuntu]", cert.ValidPrincipals)
+	}
+}
+
+// writeTestCAKey generates an SSH CA private key in dir and returns its path,
+// for exercising LocalSignerConfig without a Vault SSH secrets engine.
+func writeTestCAKey(t *testing.T, dir string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate ca key: %s", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	path := filepath.Join(dir, "ca-key")
+	if err := ioutil.WriteFile(path, keyPEM, 0o600); err != nil {
+		t.Fatalf("write ca key: %s", err)
+	}
+	return path
+}
+
+func mustMarshal(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+	return b
+}
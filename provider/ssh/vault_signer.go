@@ -0,0 +1,65 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/telia-oss/sidecred"
+	vaultinternal "github.com/telia-oss/sidecred/internal/vault"
+)
+
+// VaultSignerConfig signs certificates using a Vault SSH secrets engine.
+type VaultSignerConfig struct {
+	Address string                    `json:"address"`
+	Auth    *vaultinternal.AuthConfig `json:"auth"`
+	Mount   string                    `json:"mount"`
+	Role    string                    `json:"role"`
+
+	// MaxLifetime bounds the TTL a requester may ask for. Left unset, no
+	// client-side limit is enforced and the role's own TTL applies.
+	MaxLifetime time.Duration `json:"max_ttl,omitempty"`
+}
+
+// MaxTTL implements Signer.
+func (c *VaultSignerConfig) MaxTTL() (time.Duration, error) {
+	if c.Mount == "" || c.Role == "" {
+		return 0, fmt.Errorf("%q and %q must be defined", "mount", "role")
+	}
+	return c.MaxLifetime, nil
+}
+
+// Sign implements Signer.
+func (c *VaultSignerConfig) Sign(pub ssh.PublicKey, req *RequestConfig) ([]byte, time.Time, error) {
+	client, err := vaultinternal.NewClient(c.Address, c.Auth)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	path := fmt.Sprintf("%s/sign/%s", c.Mount, c.Role)
+	secret, err := client.Write(path, map[string]interface{}{
+		"public_key":       string(ssh.MarshalAuthorizedKey(pub)),
+		"valid_principals": strings.Join(req.Principals, ","),
+		"cert_type":        string(req.CertType),
+		"key_id":           req.KeyID,
+		"ttl":              req.TTL.String(),
+		"extensions":       req.Extensions,
+		"critical_options": req.CriticalOptions,
+	})
+	if err != nil {
+		wrapped := fmt.Errorf("ssh sign: %s", err)
+		if vaultinternal.IsTransient(err) {
+			return nil, time.Time{}, sidecred.NewRecoverableError(wrapped)
+		}
+		return nil, time.Time{}, wrapped
+	}
+	if secret == nil {
+		return nil, time.Time{}, fmt.Errorf("ssh sign: empty response")
+	}
+	signed, _ := secret.Data["signed_key"].(string)
+	if signed == "" {
+		return nil, time.Time{}, fmt.Errorf("ssh sign: response missing %q", "signed_key")
+	}
+	return []byte(signed), time.Now().Add(req.TTL), nil
+}
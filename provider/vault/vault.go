@@ -0,0 +1,183 @@
+// Package vault implements a sidecred.Provider that issues credentials from
+// Vault secrets engines.
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/telia-oss/sidecred"
+	vaultinternal "github.com/telia-oss/sidecred/internal/vault"
+)
+
+func init() {
+	sidecred.RegisterProvider(sidecred.ProviderRegistration{
+		Type:        sidecred.VaultSecret,
+		NewConfig:   func() sidecred.Validatable { return &RequestConfig{} },
+		New:         newProvider,
+		Description: "Issues dynamic secrets from a Vault secrets engine (database, aws, kv-v2 read).",
+	})
+}
+
+// ConnectionConfig is the Vault address and auth block a deployment
+// configures this provider with, distinct from the per-request
+// RequestConfig every CredentialRequest carries.
+type ConnectionConfig struct {
+	Address string                    `json:"address"`
+	Auth    *vaultinternal.AuthConfig `json:"auth"`
+}
+
+// newProvider implements sidecred.ProviderFactory by authenticating a Vault
+// client from config and handing it to New.
+func newProvider(config json.RawMessage) (sidecred.Provider, error) {
+	c := &ConnectionConfig{}
+	if err := sidecred.UnmarshalConfig(config, c); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %s", err)
+	}
+	client, err := vaultinternal.NewClient(c.Address, c.Auth)
+	if err != nil {
+		return nil, err
+	}
+	return New(client), nil
+}
+
+// Engine identifies the Vault secrets engine a RequestConfig reads from.
+type Engine string
+
+// Supported secrets engines.
+const (
+	DatabaseEngine Engine = "database"
+	AWSEngine      Engine = "aws"
+	KVv2Engine     Engine = "kv-v2"
+)
+
+// RequestConfig for the Vault provider.
+type RequestConfig struct {
+	// Engine is the type of secrets engine mounted at Mount.
+	Engine Engine `json:"engine"`
+
+	// Mount is the path the secrets engine is mounted at, e.g. "database" or "aws".
+	Mount string `json:"mount"`
+
+	// Role is the Vault role to read from Mount. For the kv-v2 engine this is
+	// the secret's key instead.
+	Role string `json:"role"`
+
+	// Parameters are passed through to the secrets engine request as-is, for
+	// engines that accept additional parameters (e.g. "ttl" for aws).
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// Validate implements sidecred.Validatable.
+func (c *RequestConfig) Validate() error {
+	switch c.Engine {
+	case DatabaseEngine, AWSEngine, KVv2Engine:
+	case "":
+		return fmt.Errorf("%q must be defined", "engine")
+	default:
+		return fmt.Errorf("%q: unknown engine %q", "engine", string(c.Engine))
+	}
+	if c.Mount == "" {
+		return fmt.Errorf("%q must be defined", "mount")
+	}
+	if c.Role == "" {
+		return fmt.Errorf("%q must be defined", "role")
+	}
+	return nil
+}
+
+// Provider issues sidecred.VaultSecret credentials by reading from a Vault
+// secrets engine.
+type Provider struct {
+	client *vaultinternal.Client
+}
+
+// New Vault provider, authenticated with client.
+func New(client *vaultinternal.Client) *Provider {
+	return &Provider{client: client}
+}
+
+// Type implements sidecred.Provider.
+func (p *Provider) Type() sidecred.CredentialType {
+	return sidecred.VaultSecret
+}
+
+// Create implements sidecred.Provider.
+func (p *Provider) Create(request *sidecred.CredentialRequest) ([]*sidecred.Credential, error) {
+	c := &RequestConfig{}
+	if err := sidecred.UnmarshalConfig(request.Config, c); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %s", err)
+	}
+
+	path := fmt.Sprintf("%s/creds/%s", c.Mount, c.Role)
+	if c.Engine == KVv2Engine {
+		path = fmt.Sprintf("%s/data/%s", c.Mount, c.Role)
+	}
+
+	// All three engines are read-only from this provider's point of view:
+	// database/aws generate new leased creds on GET, and kv-v2 must never be
+	// written to just to fetch a value. Parameters (if any) go out as query
+	// data rather than a request body.
+	secret, err := p.client.ReadWithData(path, queryData(c.Parameters))
+	if err != nil {
+		wrapped := fmt.Errorf("read %s: %s", path, err)
+		if vaultinternal.IsTransient(err) {
+			return nil, sidecred.NewRecoverableError(wrapped)
+		}
+		return nil, wrapped
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("read %s: empty response", path)
+	}
+
+	rotationWindow := request.RotationWindow
+	// kv-v2 reads carry no lease (LeaseDuration == 0): the secret is static
+	// and never expires on its own, so leave Expiration at its zero value
+	// rather than backdating it to "now".
+	var expiration time.Time
+	if secret.LeaseDuration > 0 {
+		expiration = time.Now().Add(time.Duration(secret.LeaseDuration) * time.Second)
+		if rotationWindow == 0 {
+			rotationWindow = time.Duration(secret.LeaseDuration/2) * time.Second
+		}
+	}
+
+	data := secret.Data
+	if c.Engine == KVv2Engine {
+		// kv-v2 nests the stored key/value pairs under "data", next to
+		// "metadata".
+		if inner, ok := data["data"].(map[string]interface{}); ok {
+			data = inner
+		}
+	}
+
+	var credentials []*sidecred.Credential
+	for k, v := range data {
+		value, ok := v.(string)
+		if !ok {
+			continue
+		}
+		credentials = append(credentials, &sidecred.Credential{
+			Name:           fmt.Sprintf("%s-%s", request.Name, k),
+			Value:          value,
+			Expiration:     expiration,
+			RotationWindow: rotationWindow,
+		})
+	}
+	return credentials, nil
+}
+
+// queryData converts parameters into the url.Values Logical().ReadWithData
+// sends as query string data, since Vault's read endpoints take parameters
+// that way rather than in a request body.
+func queryData(parameters map[string]interface{}) map[string][]string {
+	if len(parameters) == 0 {
+		return nil
+	}
+	data := make(map[string][]string, len(parameters))
+	for k, v := range parameters {
+		data[k] = []string{fmt.Sprintf("%v", v)}
+	}
+	return data
+}
@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/telia-oss/sidecred"
+	vaultinternal "github.com/telia-oss/sidecred/internal/vault"
+)
+
+func TestRequestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *RequestConfig
+		wantErr bool
+	}{
+		{
+			name:    "valid database",
+			config:  &RequestConfig{Engine: DatabaseEngine, Mount: "database", Role: "readonly"},
+			wantErr: false,
+		},
+		{
+			name:    "missing engine",
+			config:  &RequestConfig{Mount: "database", Role: "readonly"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown engine",
+			config:  &RequestConfig{Engine: "ldap", Mount: "ldap", Role: "readonly"},
+			wantErr: true,
+		},
+		{
+			name:    "missing mount",
+			config:  &RequestConfig{Engine: KVv2Engine, Role: "readonly"},
+			wantErr: true,
+		},
+		{
+			name:    "missing role",
+			config:  &RequestConfig{Engine: AWSEngine, Mount: "aws"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestProviderCreateReadsSecretsEngine asserts that Create only ever issues
+// GET requests against Vault, for every supported engine -- a Write here
+// would either be rejected (database/aws are read-only) or silently
+// overwrite the stored secret (kv-v2).
+func TestProviderCreateReadsSecretsEngine(t *testing.T) {
+	tests := []struct {
+		engine   Engine
+		wantPath string
+	}{
+		{engine: DatabaseEngine, wantPath: "/v1/database/creds/readonly"},
+		{engine: AWSEngine, wantPath: "/v1/aws/creds/readonly"},
+		{engine: KVv2Engine, wantPath: "/v1/kv/data/readonly"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.engine), func(t *testing.T) {
+			var gotMethod, gotPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod, gotPath = r.Method, r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"lease_duration": 60,
+					"data":           map[string]interface{}{"username": "u", "password": "p"},
+				})
+			}))
+			defer server.Close()
+
+			client, err := vaultinternal.NewClient(server.URL, &vaultinternal.AuthConfig{Token: "root"})
+			if err != nil {
+				t.Fatalf("NewClient() = %s", err)
+			}
+
+			mount := "kv"
+			if tt.engine != KVv2Engine {
+				mount = string(tt.engine)
+			}
+			p := New(client)
+			request := &sidecred.CredentialRequest{
+				Name: "test",
+				Config: mustMarshal(t, &RequestConfig{
+					Engine: tt.engine,
+					Mount:  mount,
+					Role:   "readonly",
+				}),
+			}
+			if _, err := p.Create(request); err != nil {
+				t.Fatalf("Create() = %s", err)
+			}
+			if gotMethod != http.MethodGet {
+				t.Fatalf("method = %q, want GET", gotMethod)
+			}
+			if gotPath != tt.wantPath {
+				t.Fatalf("path = %q, want %q", gotPath, tt.wantPath)
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+	return b
+}
@@ -0,0 +1,106 @@
+package sidecred
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ProviderInfo describes a registered provider: its CredentialType, human
+// readable description, and a schema for the config its requests take.
+type ProviderInfo struct {
+	Type         CredentialType  `json:"type"`
+	Description  string          `json:"description"`
+	ConfigSchema json.RawMessage `json:"config_schema"`
+}
+
+// ListProviders returns ProviderInfo for every registered provider, sorted by
+// CredentialType, deriving each one's ConfigSchema from its NewConfig()
+// value. FormatProviders renders it as text.
+func ListProviders() ([]*ProviderInfo, error) {
+	regs := Providers()
+	out := make([]*ProviderInfo, 0, len(regs))
+	for _, reg := range regs {
+		schema, err := jsonSchema(reg.NewConfig())
+		if err != nil {
+			return nil, fmt.Errorf("%s: config schema: %s", reg.Type, err)
+		}
+		out = append(out, &ProviderInfo{
+			Type:         reg.Type,
+			Description:  reg.Description,
+			ConfigSchema: schema,
+		})
+	}
+	return out, nil
+}
+
+// FormatProviders renders ListProviders as one "<type>\t<description>" line
+// per provider. ConfigSchema is not included, and no CLI command prints these
+// lines.
+func FormatProviders() (string, error) {
+	infos, err := ListProviders()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, info := range infos {
+		fmt.Fprintf(&b, "%s\t%s\n", info.Type, info.Description)
+	}
+	return b.String(), nil
+}
+
+// jsonSchema produces a shallow {"type":"object","properties":{...}} schema
+// from v's exported fields and their json tags. It describes top-level shape
+// only (field name and a coarse string/number/boolean/object/array kind) --
+// enough for CLI help text, not full JSON Schema validation.
+func jsonSchema(v Validatable) (json.RawMessage, error) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return json.Marshal(map[string]string{"type": jsonSchemaKind(t.Kind())})
+	}
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if n := strings.Split(tag, ",")[0]; n != "" && n != "-" {
+				name = n
+			}
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		properties[name] = map[string]string{"type": jsonSchemaKind(ft.Kind())}
+	}
+	return json.Marshal(map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	})
+}
+
+func jsonSchemaKind(k reflect.Kind) string {
+	switch k {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "string"
+	}
+}
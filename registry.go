@@ -0,0 +1,163 @@
+package sidecred
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ProviderConfigFactory returns a new, zero-valued Validatable config for a
+// CredentialType. The returned value is unmarshalled from the request's raw
+// JSON config and then validated.
+type ProviderConfigFactory func() Validatable
+
+// ProviderFactory builds a ready-to-use Provider for a CredentialType from
+// its provider-level configuration (e.g. a Vault address and auth block) --
+// as opposed to NewConfig, which is invoked once per CredentialRequest.
+// config is the raw JSON of that provider's entry in the deployment's
+// provider configuration; a provider with no such configuration (pki, ssh)
+// ignores it. Most callers reach this through NewProvider rather than
+// calling it directly.
+type ProviderFactory func(config json.RawMessage) (Provider, error)
+
+// ProviderRegistration describes a provider that has made itself available
+// for use in request configuration.
+type ProviderRegistration struct {
+	// Type is the CredentialType the provider issues.
+	Type CredentialType
+
+	// NewConfig returns a new Validatable config for Type.
+	NewConfig ProviderConfigFactory
+
+	// New builds a Provider for Type, ready to have Create called on it. This
+	// is what lets a CredentialType -> Provider map be built generically,
+	// without the caller hand-wiring every provider's constructor.
+	New ProviderFactory
+
+	// Description is a short, human readable summary, included by
+	// ListProviders and FormatProviders.
+	Description string
+}
+
+// providerRegistry holds providers registered with RegisterProvider, keyed by
+// the CredentialType they issue.
+var providerRegistry = map[CredentialType]*ProviderRegistration{}
+
+// RegisterProvider registers a provider's CredentialType and config factory.
+// Providers are expected to call this from an init() function, so that
+// importing the provider package for its side effect is enough to make it
+// available to config.Parse and v1.Validate.
+//
+// RegisterProvider panics if reg.Type has already been registered, since that
+// indicates two providers colliding on the same CredentialType at link time,
+// or if New is nil, since that would turn into a nil pointer panic on the
+// first call to NewProvider rather than a clear failure at registration.
+func RegisterProvider(reg ProviderRegistration) {
+	if _, found := providerRegistry[reg.Type]; found {
+		panic(fmt.Sprintf("provider for type %q already registered", string(reg.Type)))
+	}
+	if reg.New == nil {
+		panic(fmt.Sprintf("provider for type %q registered without a New constructor", string(reg.Type)))
+	}
+	providerRegistry[reg.Type] = &reg
+}
+
+// ProviderConfig returns a new Validatable config for t, or an error if no
+// provider has registered itself for that CredentialType.
+func ProviderConfig(t CredentialType) (Validatable, error) {
+	reg, found := providerRegistry[t]
+	if !found {
+		return nil, fmt.Errorf("unknown type %q", string(t))
+	}
+	return reg.NewConfig(), nil
+}
+
+// NewProvider builds a Provider for t using its registered ProviderFactory
+// and config, or an error if no provider has registered itself for that
+// CredentialType.
+func NewProvider(t CredentialType, config json.RawMessage) (Provider, error) {
+	reg, found := providerRegistry[t]
+	if !found {
+		return nil, fmt.Errorf("unknown type %q", string(t))
+	}
+	return reg.New(config)
+}
+
+// Providers returns the registered provider types, sorted by CredentialType.
+func Providers() []*ProviderRegistration {
+	out := make([]*ProviderRegistration, 0, len(providerRegistry))
+	for _, reg := range providerRegistry {
+		out = append(out, reg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
+
+// StoreFactory builds a ready-to-use Store for a StoreType from its
+// provider-level configuration (e.g. a Vault address and auth block, plus
+// the store's own mount/path). config is the raw JSON of that store's entry
+// in CredentialStores. Most callers reach this through NewStore rather than
+// calling it directly.
+type StoreFactory func(config json.RawMessage) (Store, error)
+
+// StoreRegistration describes a store that has made itself available for use
+// as a credential destination.
+type StoreRegistration struct {
+	// Type is the StoreType the store implements.
+	Type StoreType
+
+	// New builds a Store for Type, ready to have Write called on it. This is
+	// what lets a StoreType -> Store map be built generically, without the
+	// caller hand-wiring every store's constructor.
+	New StoreFactory
+
+	// Description is a short, human readable summary of the store. It is not
+	// included by ListProviders or FormatProviders, which cover providers only.
+	Description string
+}
+
+// storeRegistry holds stores registered with RegisterStore, keyed by StoreType.
+var storeRegistry = map[StoreType]*StoreRegistration{}
+
+// RegisterStore registers a store's StoreType. Stores are expected to call
+// this from an init() function, so that importing the store package for its
+// side effect is enough to make it a valid target in CredentialStores.
+//
+// RegisterStore panics if reg.Type has already been registered, or if New is nil,
+// since that would turn into a nil pointer panic on the first call to
+// NewStore rather than a clear failure at registration.
+func RegisterStore(reg StoreRegistration) {
+	if _, found := storeRegistry[reg.Type]; found {
+		panic(fmt.Sprintf("store for type %q already registered", string(reg.Type)))
+	}
+	if reg.New == nil {
+		panic(fmt.Sprintf("store for type %q registered without a New constructor", string(reg.Type)))
+	}
+	storeRegistry[reg.Type] = &reg
+}
+
+// IsRegisteredStore reports whether t has been registered with RegisterStore.
+func IsRegisteredStore(t StoreType) bool {
+	_, found := storeRegistry[t]
+	return found
+}
+
+// NewStore builds a Store for t using its registered StoreFactory and
+// config, or an error if no store has registered itself for that StoreType.
+func NewStore(t StoreType, config json.RawMessage) (Store, error) {
+	reg, found := storeRegistry[t]
+	if !found {
+		return nil, fmt.Errorf("unknown type %q", string(t))
+	}
+	return reg.New(config)
+}
+
+// Stores returns the registered store types, sorted by StoreType.
+func Stores() []*StoreRegistration {
+	out := make([]*StoreRegistration, 0, len(storeRegistry))
+	for _, reg := range storeRegistry {
+		out = append(out, reg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
@@ -0,0 +1,104 @@
+package sidecred
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// fakeValidatable is a minimal Validatable used to exercise RegisterProvider
+// without depending on a real provider package.
+type fakeValidatable struct{}
+
+func (fakeValidatable) Validate() error { return nil }
+
+// fakeProvider is a minimal Provider used to exercise ProviderFactory.
+type fakeProvider struct {
+	typ CredentialType
+}
+
+func (p *fakeProvider) Type() CredentialType                             { return p.typ }
+func (p *fakeProvider) Create(*CredentialRequest) ([]*Credential, error) { return nil, nil }
+
+// fakeStore is a minimal Store used to exercise StoreFactory.
+type fakeStore struct {
+	typ StoreType
+}
+
+func (s *fakeStore) Type() StoreType                   { return s.typ }
+func (s *fakeStore) Write(string, []*Credential) error { return nil }
+
+func TestNewProviderUsesRegisteredFactory(t *testing.T) {
+	const typ CredentialType = "test:provider"
+	RegisterProvider(ProviderRegistration{
+		Type:      typ,
+		NewConfig: func() Validatable { return &fakeValidatable{} },
+		New: func(config json.RawMessage) (Provider, error) {
+			var c struct {
+				Label string `json:"label"`
+			}
+			if err := json.Unmarshal(config, &c); err != nil {
+				return nil, err
+			}
+			return &fakeProvider{typ: CredentialType(c.Label)}, nil
+		},
+	})
+
+	p, err := NewProvider(typ, json.RawMessage(`{"label":"test:provider"}`))
+	if err != nil {
+		t.Fatalf("NewProvider() = %s", err)
+	}
+	if p.Type() != typ {
+		t.Fatalf("Type() = %q, want %q", p.Type(), typ)
+	}
+}
+
+func TestNewProviderUnknownType(t *testing.T) {
+	if _, err := NewProvider("test:unknown-provider", nil); err == nil {
+		t.Fatal("NewProvider() = nil error, want error for unregistered type")
+	}
+}
+
+func TestNewStoreUsesRegisteredFactory(t *testing.T) {
+	const typ StoreType = "test:store"
+	RegisterStore(StoreRegistration{
+		Type: typ,
+		New: func(config json.RawMessage) (Store, error) {
+			return &fakeStore{typ: typ}, nil
+		},
+	})
+
+	s, err := NewStore(typ, nil)
+	if err != nil {
+		t.Fatalf("NewStore() = %s", err)
+	}
+	if s.Type() != typ {
+		t.Fatalf("Type() = %q, want %q", s.Type(), typ)
+	}
+}
+
+func TestNewStoreUnknownType(t *testing.T) {
+	if _, err := NewStore("test:unknown-store", nil); err == nil {
+		t.Fatal("NewStore() = nil error, want error for unregistered type")
+	}
+}
+
+func TestRegisterProviderPanicsWithoutNew(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RegisterProvider() did not panic for a registration missing New")
+		}
+	}()
+	RegisterProvider(ProviderRegistration{
+		Type:      "test:provider-without-new",
+		NewConfig: func() Validatable { return &fakeValidatable{} },
+	})
+}
+
+func TestRegisterStorePanicsWithoutNew(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RegisterStore() did not panic for a registration missing New")
+		}
+	}()
+	RegisterStore(StoreRegistration{Type: "test:store-without-new"})
+}
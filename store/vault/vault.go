@@ -0,0 +1,94 @@
+// Package vault implements a sidecred.Store that writes issued credentials to
+// a Vault KV v2 path.
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/telia-oss/sidecred"
+	vaultinternal "github.com/telia-oss/sidecred/internal/vault"
+)
+
+func init() {
+	sidecred.RegisterStore(sidecred.StoreRegistration{
+		Type:        sidecred.Vault,
+		New:         newStore,
+		Description: "Writes issued credentials to a versioned Vault KV v2 path.",
+	})
+}
+
+// Config for the Vault store. Address and Auth configure the shared Vault
+// client; Mount/Path/CAS select where credentials are written.
+type Config struct {
+	Address string                    `json:"address"`
+	Auth    *vaultinternal.AuthConfig `json:"auth"`
+
+	// Mount is the path the kv-v2 secrets engine is mounted at.
+	Mount string `json:"mount"`
+
+	// Path is the secret path credentials are written to, below
+	// Mount/data/<namespace>.
+	Path string `json:"path"`
+
+	// CAS, when set, is passed as the check-and-set index on write so
+	// concurrent writers can detect conflicting updates.
+	CAS *int `json:"cas,omitempty"`
+}
+
+// newStore implements sidecred.StoreFactory by authenticating a Vault client
+// from config and handing it to New.
+func newStore(config json.RawMessage) (sidecred.Store, error) {
+	c := &Config{}
+	if err := sidecred.UnmarshalConfig(config, c); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %s", err)
+	}
+	client, err := vaultinternal.NewClient(c.Address, c.Auth)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, c), nil
+}
+
+// Store writes sidecred.CredentialsMap entries to a Vault KV v2 path.
+type Store struct {
+	client *vaultinternal.Client
+	config *Config
+}
+
+// New Vault store, authenticated with client and writing to config.Path.
+func New(client *vaultinternal.Client, config *Config) *Store {
+	return &Store{client: client, config: config}
+}
+
+// Type implements sidecred.Store.
+func (s *Store) Type() sidecred.StoreType {
+	return sidecred.Vault
+}
+
+// Write implements sidecred.Store. credentials are the issued secrets
+// produced by a provider's Create, not the pre-issuance
+// *sidecred.CredentialsMap a request resolves to -- that type only carries
+// CredentialRequests, which have no values to write.
+func (s *Store) Write(namespace string, credentials []*sidecred.Credential) error {
+	data := make(map[string]interface{}, len(credentials))
+	for _, c := range credentials {
+		data[c.Name] = c.Value
+	}
+	options := map[string]interface{}{}
+	if s.config.CAS != nil {
+		options["cas"] = *s.config.CAS
+	}
+	path := fmt.Sprintf("%s/data/%s/%s", s.config.Mount, namespace, s.config.Path)
+	if _, err := s.client.Write(path, map[string]interface{}{
+		"data":    data,
+		"options": options,
+	}); err != nil {
+		wrapped := fmt.Errorf("write %s: %s", path, err)
+		if vaultinternal.IsTransient(err) {
+			return sidecred.NewRecoverableError(wrapped)
+		}
+		return wrapped
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/telia-oss/sidecred"
+	vaultinternal "github.com/telia-oss/sidecred/internal/vault"
+)
+
+func TestStoreWrite(t *testing.T) {
+	var gotMethod, gotPath string
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{})
+	}))
+	defer server.Close()
+
+	client, err := vaultinternal.NewClient(server.URL, &vaultinternal.AuthConfig{Token: "root"})
+	if err != nil {
+		t.Fatalf("NewClient() = %s", err)
+	}
+
+	cas := 3
+	s := New(client, &Config{Mount: "secret", Path: "app/creds", CAS: &cas})
+	credentials := []*sidecred.Credential{
+		{Name: "username", Value: "u"},
+		{Name: "password", Value: "p"},
+	}
+
+	if err := s.Write("production", credentials); err != nil {
+		t.Fatalf("Write() = %s", err)
+	}
+	if gotMethod != http.MethodPut && gotMethod != http.MethodPost {
+		t.Fatalf("method = %q, want PUT/POST", gotMethod)
+	}
+	if want := "/v1/secret/data/production/app/creds"; gotPath != want {
+		t.Fatalf("path = %q, want %q", gotPath, want)
+	}
+	data, _ := gotBody["data"].(map[string]interface{})
+	if data["username"] != "u" || data["password"] != "p" {
+		t.Fatalf("data = %+v, want username/password", data)
+	}
+	options, _ := gotBody["options"].(map[string]interface{})
+	if options["cas"] != float64(3) {
+		t.Fatalf("options.cas = %v, want 3", options["cas"])
+	}
+}